@@ -0,0 +1,115 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/danesparza/fxtrigger/internal/apiscope"
+	"github.com/danesparza/fxtrigger/internal/data"
+	"github.com/rs/zerolog/log"
+)
+
+// APIKeyHeader is the header an API key can be sent in (instead of 'Authorization: Bearer <key>')
+const APIKeyHeader = "X-API-Key"
+
+// Authenticate is middleware that makes sure each request has an API key with the scope it needs.
+// Until the first API key is created, requests made on the device itself (from a loopback address) are
+// allowed, so the first key can be created
+func (service Service) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+
+		//	See what the request needs
+		scope := requiredScope(req)
+		if scope == "" {
+			next.ServeHTTP(rw, req)
+			return
+		}
+
+		//	Get the key from the request
+		key := apiKeyFromRequest(req)
+		if key == "" {
+			if service.bootstrapping(req) {
+				next.ServeHTTP(rw, req)
+				return
+			}
+
+			rw.Header().Set("WWW-Authenticate", "Bearer")
+			sendErrorResponse(rw, fmt.Errorf("an API key is required"), http.StatusUnauthorized)
+			return
+		}
+
+		//	Make sure it's valid, and allows the request
+		apiKey, err := service.DB.AuthenticateAPIKey(key)
+		if err != nil {
+			rw.Header().Set("WWW-Authenticate", "Bearer")
+			sendErrorResponse(rw, err, http.StatusUnauthorized)
+			return
+		}
+
+		if !hasScope(apiKey, scope) {
+			log.Debug().Str("APIKeyID", apiKey.ID).Str("scope", scope).Str("path", req.URL.Path).Msg("API key doesn't have the scope for the request")
+			sendErrorResponse(rw, fmt.Errorf("the API key doesn't have the '%v' scope", scope), http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(rw, req)
+	})
+}
+
+// requiredScope returns the API key scope a request needs (or an empty string if it doesn't need an API key)
+func requiredScope(req *http.Request) string {
+	switch {
+	case strings.HasPrefix(req.URL.Path, "/v1/swagger"), req.Method == http.MethodOptions:
+		return ""
+	case strings.HasPrefix(req.URL.Path, "/v1/keys"):
+		return apiscope.Admin
+	case strings.HasPrefix(req.URL.Path, "/v1/trigger/fire/"):
+		return apiscope.Fire
+	case req.Method == http.MethodGet, req.Method == http.MethodHead:
+		return apiscope.Read
+	default:
+		return apiscope.Write
+	}
+}
+
+// apiKeyFromRequest gets the API key from the Authorization (bearer) or X-API-Key header
+func apiKeyFromRequest(req *http.Request) string {
+	if key := strings.TrimSpace(req.Header.Get(APIKeyHeader)); key != "" {
+		return key
+	}
+
+	scheme, key, found := strings.Cut(req.Header.Get("Authorization"), " ")
+	if found && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(key)
+	}
+
+	return ""
+}
+
+// hasScope returns true if the API key allows the scope
+func hasScope(apiKey data.APIKey, scope string) bool {
+	return slices.Contains(apiKey.Scopes, scope) || slices.Contains(apiKey.Scopes, apiscope.Admin)
+}
+
+// bootstrapping returns true if no API keys have been created yet and the request was made
+// on the device itself.  Proxied requests don't count (the proxy might be on the device)
+func (service Service) bootstrapping(req *http.Request) bool {
+	if req.Header.Get("X-Forwarded-For") != "" {
+		return false
+	}
+
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return false
+	}
+
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return false
+	}
+
+	keys, err := service.DB.GetAllAPIKeys()
+	return err == nil && len(keys) == 0
+}
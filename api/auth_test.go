@@ -0,0 +1,156 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/danesparza/fxtrigger/api"
+)
+
+// The addresses requests come from
+const (
+	localAddr  = "127.0.0.1:40000"
+	remoteAddr = "192.168.1.50:40000"
+)
+
+// createKey creates an API key (using the admin key, or on the device if there isn't one yet) and returns the key
+func createKey(t *testing.T, h *simHarness, adminKey string, scopes ...string) string {
+	t.Helper()
+
+	rr := h.callWithKey(http.MethodPost, "/v1/keys", localAddr, adminKey, api.CreateAPIKeyRequest{Name: "Unit test", Scopes: scopes})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("CreateAPIKey failed: %v %s", rr.Code, rr.Body.String())
+	}
+
+	response := struct {
+		Data api.APIKeyResponse `json:"data"`
+	}{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("CreateAPIKey failed: %s", err)
+	}
+
+	return response.Data.Key
+}
+
+func TestAuth_Authenticate_NoKeysRemoteRequest_Unauthorized(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+
+	//	Act
+	rr := h.callWithKey(http.MethodGet, "/v1/triggers", remoteAddr, "", nil)
+
+	//	Assert
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Authenticate failed: Should have returned unauthorized but got: %v", rr.Code)
+	}
+}
+
+func TestAuth_Authenticate_KeyCreated_NoLongerAllowsLocalRequests(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+	createKey(t, h, "", "admin")
+
+	//	Act
+	rr := h.callWithKey(http.MethodGet, "/v1/triggers", localAddr, "", nil)
+
+	//	Assert
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Authenticate failed: Should have returned unauthorized but got: %v", rr.Code)
+	}
+}
+
+func TestAuth_Authenticate_Scopes_EnforcedForEachRoute(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+	adminKey := createKey(t, h, "", "admin")
+	readKey := createKey(t, h, adminKey, "read")
+
+	//	Act
+	read := h.callWithKey(http.MethodGet, "/v1/triggers", remoteAddr, readKey, nil)
+	write := h.callWithKey(http.MethodDelete, "/v1/triggers/unknown", remoteAddr, readKey, nil)
+	fire := h.callWithKey(http.MethodPost, "/v1/trigger/fire/unknown", remoteAddr, readKey, nil)
+	keys := h.callWithKey(http.MethodGet, "/v1/keys", remoteAddr, readKey, nil)
+	invalid := h.callWithKey(http.MethodGet, "/v1/triggers", remoteAddr, readKey+"0", nil)
+
+	//	Assert
+	if read.Code != http.StatusOK {
+		t.Errorf("Authenticate failed: Should have allowed the read scope to list triggers but got: %v", read.Code)
+	}
+
+	if write.Code != http.StatusForbidden || fire.Code != http.StatusForbidden || keys.Code != http.StatusForbidden {
+		t.Errorf("Authenticate failed: Should have forbidden write, fire and admin requests but got: %v, %v, %v", write.Code, fire.Code, keys.Code)
+	}
+
+	if invalid.Code != http.StatusUnauthorized {
+		t.Errorf("Authenticate failed: Should have returned unauthorized for an invalid key but got: %v", invalid.Code)
+	}
+}
+
+func TestKeys_ListAllAPIKeys_DoesNotIncludeKeys(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+	adminKey := createKey(t, h, "", "admin")
+
+	//	Act
+	rr := h.callWithKey(http.MethodGet, "/v1/keys", remoteAddr, adminKey, nil)
+
+	//	Assert
+	response := struct {
+		Data []map[string]any `json:"data"`
+	}{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("ListAllAPIKeys failed: %s", err)
+	}
+
+	if len(response.Data) != 1 {
+		t.Fatalf("ListAllAPIKeys failed: Should have listed one key but got: %v", len(response.Data))
+	}
+
+	if _, exists := response.Data[0]["key"]; exists {
+		t.Errorf("ListAllAPIKeys failed: Should not include the key")
+	}
+
+	if _, exists := response.Data[0]["hash"]; exists {
+		t.Errorf("ListAllAPIKeys failed: Should not include the key hash")
+	}
+}
+
+func TestKeys_DeleteAPIKey_LastAdminKey_Conflict(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+	adminKey := createKey(t, h, "", "admin")
+	h.callWithKey(http.MethodPost, "/v1/keys", remoteAddr, adminKey, api.CreateAPIKeyRequest{Name: "Reader", Scopes: []string{"read"}})
+
+	keys := struct {
+		Data []api.APIKeyResponse `json:"data"`
+	}{}
+	json.Unmarshal(h.callWithKey(http.MethodGet, "/v1/keys", remoteAddr, adminKey, nil).Body.Bytes(), &keys)
+
+	//	Act
+	rr := h.callWithKey(http.MethodDelete, "/v1/keys/"+keys.Data[0].ID, remoteAddr, adminKey, nil)
+
+	//	Assert
+	if rr.Code != http.StatusConflict {
+		t.Errorf("DeleteAPIKey failed: Should have returned a conflict but got: %v %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestKeys_CreateAPIKey_InvalidScope_BadRequest(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+
+	//	Act
+	rr := h.callWithKey(http.MethodPost, "/v1/keys", localAddr, "", api.CreateAPIKeyRequest{Name: "Unit test", Scopes: []string{"everything"}})
+
+	//	Assert
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("CreateAPIKey failed: Should have returned a bad request but got: %v", rr.Code)
+	}
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/danesparza/fxtrigger/internal/data"
+	"github.com/danesparza/fxtrigger/internal/trigger"
+	"github.com/rs/zerolog/log"
+)
+
+// DNDResponse is the do not disturb settings, along with whether do not disturb is active right now
+type DNDResponse struct {
+	data.DNDSettings
+	Active bool `json:"active"` // Do not disturb is active right now
+}
+
+// GetDND godoc
+// @Summary Get the do not disturb settings
+// @Description Get the do not disturb settings, and whether do not disturb is active right now
+// @Tags dnd
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} api.SystemResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /dnd [get]
+func (service Service) GetDND(rw http.ResponseWriter, req *http.Request) {
+
+	//	Get the current settings
+	settings, _, err := service.DB.GetDNDSettings()
+	if err != nil {
+		sendErrorResponse(rw, err, http.StatusInternalServerError)
+		return
+	}
+
+	//	Construct our response
+	response := SystemResponse{
+		Message: "Do not disturb settings",
+		Data:    DNDResponse{DNDSettings: settings, Active: trigger.DNDActive(settings, time.Now())},
+	}
+
+	//	Serialize to JSON & return the response:
+	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(rw).Encode(response)
+}
+
+// UpdateDND godoc
+// @Summary Update the do not disturb settings
+// @Description Replace the do not disturb settings.  Use override (on or off) to override the windows, optionally until overrideuntil
+// @Tags dnd
+// @Accept  json
+// @Produce  json
+// @Param settings body data.DNDSettings true "The do not disturb settings"
+// @Success 200 {object} api.SystemResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /dnd [put]
+func (service Service) UpdateDND(rw http.ResponseWriter, req *http.Request) {
+
+	//	req.Body is a ReadCloser -- we need to remember to close it:
+	defer req.Body.Close()
+
+	//	Decode the request
+	request := data.DNDSettings{}
+	if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+		sendErrorResponse(rw, err, http.StatusBadRequest)
+		return
+	}
+
+	//	Make sure the settings are valid
+	if err := trigger.ValidateDNDSettings(request); err != nil {
+		sendErrorResponse(rw, err, http.StatusBadRequest)
+		return
+	}
+
+	//	Save the settings
+	settings, err := service.DB.SaveDNDSettings(request)
+	if err != nil {
+		sendErrorResponse(rw, err, http.StatusInternalServerError)
+		return
+	}
+
+	//	Record the event:
+	log.Debug().Any("request", request).Msg("Do not disturb settings updated")
+
+	//	Create our response and send information back:
+	response := SystemResponse{
+		Message: "Do not disturb settings updated",
+		Data:    DNDResponse{DNDSettings: settings, Active: trigger.DNDActive(settings, time.Now())},
+	}
+
+	//	Serialize to JSON & return the response:
+	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(rw).Encode(response)
+}
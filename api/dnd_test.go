@@ -0,0 +1,106 @@
+package api_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/danesparza/fxtrigger/api"
+	"github.com/danesparza/fxtrigger/internal/data"
+)
+
+func TestDND_UpdateDND_Suppress_DoesNotFire(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+	rr := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:     "Sim sensor",
+		GPIOPin:  17,
+		WebHooks: []data.WebHook{{URL: h.hookTS.URL}},
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("CreateTrigger failed: %v %s", rr.Code, rr.Body.String())
+	}
+
+	//	Act
+	rr = h.call(http.MethodPut, "/v1/dnd", data.DNDSettings{Mode: "suppress", Override: "on"})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("UpdateDND failed: %v %s", rr.Code, rr.Body.String())
+	}
+	h.call(http.MethodPost, "/v1/sim/pins/17", api.SetSimPinRequest{Action: "pulse", PulseMillis: 100})
+
+	//	Assert
+	if count := h.hookCount(1 * time.Second); count != 0 {
+		t.Errorf("UpdateDND failed: Should have suppressed the webhook but got: %v", count)
+	}
+}
+
+func TestDND_UpdateDND_IgnoreDND_Fires(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+	rr := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:      "Sim sensor",
+		GPIOPin:   17,
+		IgnoreDND: true,
+		WebHooks:  []data.WebHook{{URL: h.hookTS.URL}},
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("CreateTrigger failed: %v %s", rr.Code, rr.Body.String())
+	}
+
+	//	Act
+	h.call(http.MethodPut, "/v1/dnd", data.DNDSettings{Override: "on"})
+	h.call(http.MethodPost, "/v1/sim/pins/17", api.SetSimPinRequest{Action: "pulse", PulseMillis: 100})
+
+	//	Assert
+	if count := h.hookCount(1 * time.Second); count != 1 {
+		t.Errorf("UpdateDND failed: Should have ignored do not disturb but got: %v", count)
+	}
+}
+
+func TestDND_UpdateDND_Queue_FiresWhenDNDEnds(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+	rr := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:     "Sim sensor",
+		GPIOPin:  17,
+		WebHooks: []data.WebHook{{URL: h.hookTS.URL}},
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("CreateTrigger failed: %v %s", rr.Code, rr.Body.String())
+	}
+	h.call(http.MethodPut, "/v1/dnd", data.DNDSettings{Mode: "queue", Override: "on"})
+
+	//	Act
+	for i := 0; i < 3; i++ {
+		h.call(http.MethodPost, "/v1/sim/pins/17", api.SetSimPinRequest{Action: "pulse", PulseMillis: 50})
+		time.Sleep(150 * time.Millisecond)
+	}
+	queuedCount := h.hookCount(1 * time.Second)
+	h.call(http.MethodPut, "/v1/dnd", data.DNDSettings{Mode: "queue"})
+
+	//	Assert
+	if queuedCount != 0 {
+		t.Errorf("UpdateDND failed: Should have queued the webhooks but got: %v", queuedCount)
+	}
+
+	if count := h.hookCount(3 * time.Second); count != 1 {
+		t.Errorf("UpdateDND failed: Should have sent the latest queued fire once but got: %v", count)
+	}
+}
+
+func TestDND_UpdateDND_InvalidWindow_BadRequest(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+
+	//	Act
+	rr := h.call(http.MethodPut, "/v1/dnd", data.DNDSettings{Enabled: true, Windows: []data.DNDWindow{{Start: "late", End: "early"}}})
+
+	//	Assert
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("UpdateDND failed: Should have returned a bad request but got: %v", rr.Code)
+	}
+}
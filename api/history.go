@@ -0,0 +1,141 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/danesparza/fxtrigger/internal/data"
+	"github.com/gorilla/mux"
+)
+
+// defaultHistoryLimit is the number of history items returned when no limit is passed
+const defaultHistoryLimit = 100
+
+// ListAllHistory godoc
+// @Summary List trigger firing history
+// @Description List trigger firing history (newest first)
+// @Tags history
+// @Accept  json
+// @Produce  json
+// @Param start query string false "Only include items at or after this time (RFC3339)"
+// @Param end query string false "Only include items before this time (RFC3339)"
+// @Param offset query int false "The number of items to skip"
+// @Param limit query int false "The maximum number of items to return (default 100)"
+// @Success 200 {object} api.SystemResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /history [get]
+func (service Service) ListAllHistory(rw http.ResponseWriter, req *http.Request) {
+
+	//	Get the filter from the query string
+	filter, err := parseHistoryFilter(req)
+	if err != nil {
+		sendErrorResponse(rw, err, http.StatusBadRequest)
+		return
+	}
+
+	service.sendHistory(rw, filter)
+}
+
+// ListTriggerHistory godoc
+// @Summary List firing history for a single trigger
+// @Description List firing history for a single trigger (newest first)
+// @Tags history
+// @Accept  json
+// @Produce  json
+// @Param id path string true "The trigger id"
+// @Param start query string false "Only include items at or after this time (RFC3339)"
+// @Param end query string false "Only include items before this time (RFC3339)"
+// @Param offset query int false "The number of items to skip"
+// @Param limit query int false "The maximum number of items to return (default 100)"
+// @Success 200 {object} api.SystemResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /triggers/{id}/history [get]
+func (service Service) ListTriggerHistory(rw http.ResponseWriter, req *http.Request) {
+
+	//	Get the id from the url (if it's blank, return an error)
+	vars := mux.Vars(req)
+	if vars["id"] == "" {
+		err := fmt.Errorf("requires an id of a trigger")
+		sendErrorResponse(rw, err, http.StatusBadRequest)
+		return
+	}
+
+	//	Get the filter from the query string
+	filter, err := parseHistoryFilter(req)
+	if err != nil {
+		sendErrorResponse(rw, err, http.StatusBadRequest)
+		return
+	}
+	filter.TriggerID = vars["id"]
+
+	service.sendHistory(rw, filter)
+}
+
+// sendHistory gets the history for the filter and sends it as the response
+func (service Service) sendHistory(rw http.ResponseWriter, filter data.HistoryFilter) {
+
+	//	Get the list of history items
+	retval, err := service.DB.GetHistory(filter)
+	if err != nil {
+		err = fmt.Errorf("error getting history: %v", err)
+		sendErrorResponse(rw, err, http.StatusInternalServerError)
+		return
+	}
+
+	//	Construct our response
+	response := SystemResponse{
+		Message: fmt.Sprintf("%v history item(s)", len(retval)),
+		Data:    retval,
+	}
+
+	//	Serialize to JSON & return the response:
+	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(rw).Encode(response)
+}
+
+// parseHistoryFilter builds a history filter from the request query string
+func parseHistoryFilter(req *http.Request) (data.HistoryFilter, error) {
+	retval := data.HistoryFilter{Limit: defaultHistoryLimit}
+	query := req.URL.Query()
+
+	if v := query.Get("start"); v != "" {
+		start, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return retval, fmt.Errorf("start must be an RFC3339 time: %v", err)
+		}
+		retval.Start = start
+	}
+
+	if v := query.Get("end"); v != "" {
+		end, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return retval, fmt.Errorf("end must be an RFC3339 time: %v", err)
+		}
+		retval.End = end
+	}
+
+	if v := query.Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return retval, fmt.Errorf("offset must be a number zero or greater")
+		}
+		retval.Offset = offset
+	}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return retval, fmt.Errorf("limit must be a number greater than zero")
+		}
+		retval.Limit = limit
+	}
+
+	return retval, nil
+}
@@ -0,0 +1,63 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/danesparza/fxtrigger/api"
+	"github.com/danesparza/fxtrigger/internal/data"
+)
+
+func TestHistory_ListTriggerHistory_FiredTrigger_Successful(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+	rr := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:     "History trigger",
+		GPIOPin:  17,
+		WebHooks: []data.WebHook{{URL: h.hookTS.URL}},
+	})
+	created := struct{ Data data.Trigger }{}
+	json.NewDecoder(rr.Body).Decode(&created)
+
+	//	Act
+	h.call(http.MethodPost, "/v1/trigger/fire/"+created.Data.ID, nil)
+	h.hookCount(500 * time.Millisecond)
+	rr = h.call(http.MethodGet, "/v1/triggers/"+created.Data.ID+"/history", nil)
+
+	//	Assert
+	history := struct{ Data []data.HistoryItem }{}
+	json.NewDecoder(rr.Body).Decode(&history)
+
+	if len(history.Data) != 1 {
+		t.Fatalf("ListTriggerHistory failed: Should have one history item but got: %v", len(history.Data))
+	}
+
+	if history.Data[0].Source != "API" {
+		t.Errorf("ListTriggerHistory failed: Should have recorded the API as the source but got: %v", history.Data[0].Source)
+	}
+
+	if len(history.Data[0].WebHooks) != 1 || history.Data[0].WebHooks[0].StatusCode != http.StatusOK {
+		t.Fatalf("ListTriggerHistory failed: Should have recorded the webhook outcome but got: %+v", history.Data[0].WebHooks)
+	}
+
+	if history.Data[0].WebHooks[0].ResponseSnippet != "received" {
+		t.Errorf("ListTriggerHistory failed: Should have recorded the response but got: %+v", history.Data[0].WebHooks[0])
+	}
+}
+
+func TestHistory_ListAllHistory_InvalidLimit_BadRequest(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+
+	//	Act
+	rr := h.call(http.MethodGet, "/v1/history?limit=none", nil)
+
+	//	Assert
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("ListAllHistory failed: Should have returned a bad request but got: %v", rr.Code)
+	}
+}
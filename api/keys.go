@@ -0,0 +1,209 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/danesparza/fxtrigger/internal/apiscope"
+	"github.com/danesparza/fxtrigger/internal/data"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// CreateAPIKeyRequest is a request to create an API key
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name"`   // What the key is used for
+	Scopes []string `json:"scopes"` // What the key allows: read, write, fire or admin
+}
+
+// APIKeyResponse is an API key.  The key itself is only included when it's created
+type APIKeyResponse struct {
+	ID      string    `json:"id"`            // Unique API key ID
+	Name    string    `json:"name"`          // What the key is used for
+	Scopes  []string  `json:"scopes"`        // What the key allows
+	Created time.Time `json:"created"`       // API key create time
+	Key     string    `json:"key,omitempty"` // The key to send in the 'Authorization: Bearer' or 'X-API-Key' header
+}
+
+// newAPIKeyResponse leaves out the key hash
+func newAPIKeyResponse(apiKey data.APIKey, key string) APIKeyResponse {
+	return APIKeyResponse{
+		ID:      apiKey.ID,
+		Name:    apiKey.Name,
+		Scopes:  apiKey.Scopes,
+		Created: apiKey.Created,
+		Key:     key,
+	}
+}
+
+// CreateAPIKey godoc
+// @Summary Create an API key
+// @Description Create an API key.  The key is only returned now, so keep it somewhere safe
+// @Tags keys
+// @Accept  json
+// @Produce  json
+// @Param key body api.CreateAPIKeyRequest true "The API key to create"
+// @Success 200 {object} api.SystemResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /keys [post]
+func (service Service) CreateAPIKey(rw http.ResponseWriter, req *http.Request) {
+
+	//	req.Body is a ReadCloser -- we need to remember to close it:
+	defer req.Body.Close()
+
+	//	Decode the request
+	request := CreateAPIKeyRequest{}
+	if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+		sendErrorResponse(rw, err, http.StatusBadRequest)
+		return
+	}
+
+	//	Make sure the request is valid
+	problems := fieldErrors{}
+	if strings.TrimSpace(request.Name) == "" {
+		problems.add("name", "is required")
+	}
+
+	if len(request.Scopes) == 0 {
+		problems.add("scopes", "must include at least one scope")
+	}
+
+	for i, scope := range request.Scopes {
+		if !slices.Contains(apiscope.All, scope) {
+			problems.add(fmt.Sprintf("scopes[%v]", i), "must be one of: %v", strings.Join(apiscope.All, ", "))
+		}
+	}
+
+	if err := problems.err(); err != nil {
+		sendErrorResponse(rw, err, http.StatusBadRequest)
+		return
+	}
+
+	//	Create the key
+	apiKey, key, err := service.DB.CreateAPIKey(request.Name, request.Scopes)
+	if err != nil {
+		sendErrorResponse(rw, err, http.StatusInternalServerError)
+		return
+	}
+
+	//	Record the event:
+	log.Info().Str("id", apiKey.ID).Str("name", apiKey.Name).Strs("scopes", apiKey.Scopes).Msg("API key created")
+
+	//	Create our response and send information back:
+	response := SystemResponse{
+		Message: "API key created",
+		Data:    newAPIKeyResponse(apiKey, key),
+	}
+
+	//	Serialize to JSON & return the response:
+	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(rw).Encode(response)
+}
+
+// ListAllAPIKeys godoc
+// @Summary List all API keys
+// @Description List all API keys (without the keys themselves)
+// @Tags keys
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} api.SystemResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /keys [get]
+func (service Service) ListAllAPIKeys(rw http.ResponseWriter, req *http.Request) {
+
+	//	Get a list of keys
+	apiKeys, err := service.DB.GetAllAPIKeys()
+	if err != nil {
+		sendErrorResponse(rw, err, http.StatusInternalServerError)
+		return
+	}
+
+	retval := []APIKeyResponse{}
+	for _, apiKey := range apiKeys {
+		retval = append(retval, newAPIKeyResponse(apiKey, ""))
+	}
+
+	//	Construct our response
+	response := SystemResponse{
+		Message: fmt.Sprintf("%v API key(s)", len(retval)),
+		Data:    retval,
+	}
+
+	//	Serialize to JSON & return the response:
+	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(rw).Encode(response)
+}
+
+// DeleteAPIKey godoc
+// @Summary Delete an API key
+// @Description Delete an API key.  The last key with the admin scope can't be deleted while other keys exist
+// @Tags keys
+// @Accept  json
+// @Produce  json
+// @Param id path string true "The API key id to delete"
+// @Success 200 {object} api.SystemResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 409 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /keys/{id} [delete]
+func (service Service) DeleteAPIKey(rw http.ResponseWriter, req *http.Request) {
+
+	//	Get the id from the url
+	id := mux.Vars(req)["id"]
+
+	//	Make sure the key exists
+	apiKeys, err := service.DB.GetAllAPIKeys()
+	if err != nil {
+		sendErrorResponse(rw, err, http.StatusInternalServerError)
+		return
+	}
+
+	target := data.APIKey{}
+	admins := 0
+	for _, apiKey := range apiKeys {
+		if apiKey.ID == id {
+			target = apiKey
+		}
+		if slices.Contains(apiKey.Scopes, apiscope.Admin) {
+			admins++
+		}
+	}
+
+	if target.ID != id {
+		sendErrorResponse(rw, fmt.Errorf("API key %v doesn't exist", id), http.StatusNotFound)
+		return
+	}
+
+	//	Don't lock everyone out of managing keys
+	if slices.Contains(target.Scopes, apiscope.Admin) && admins == 1 && len(apiKeys) > 1 {
+		sendErrorResponse(rw, fmt.Errorf("can't delete the last admin API key while other keys exist"), http.StatusConflict)
+		return
+	}
+
+	//	Delete the key
+	if err := service.DB.DeleteAPIKey(id); err != nil {
+		sendErrorResponse(rw, err, http.StatusInternalServerError)
+		return
+	}
+
+	//	Record the event:
+	log.Info().Str("id", id).Msg("API key deleted")
+
+	//	Construct our response
+	response := SystemResponse{
+		Message: "API key deleted",
+		Data:    id,
+	}
+
+	//	Serialize to JSON & return the response:
+	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(rw).Encode(response)
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/danesparza/fxtrigger/internal/data"
+)
+
+// patchTrigger applies a JSON merge patch (RFC 7396) to a trigger and returns the patched trigger
+func patchTrigger(t data.Trigger, patch any) (data.Trigger, error) {
+	retval := data.Trigger{}
+
+	//	The patch is applied to the JSON version of the trigger
+	encoded, err := json.Marshal(t)
+	if err != nil {
+		return retval, fmt.Errorf("problem serializing the trigger: %v", err)
+	}
+
+	var target any
+	if err := json.Unmarshal(encoded, &target); err != nil {
+		return retval, fmt.Errorf("problem serializing the trigger: %v", err)
+	}
+
+	//	A trigger can only be patched with an object
+	if _, ok := patch.(map[string]any); !ok {
+		return retval, fmt.Errorf("the patch must be a JSON object")
+	}
+
+	patched, err := json.Marshal(mergePatch(target, patch))
+	if err != nil {
+		return retval, fmt.Errorf("problem applying the patch: %v", err)
+	}
+
+	//	Don't allow settings the trigger doesn't have (they're probably typos).  Settings that are only
+	//	returned (like nextfiretime) are allowed, so a trigger that was returned can be sent back
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	returned := TriggerResponse{}
+	if err := decoder.Decode(&returned); err != nil {
+		return data.Trigger{}, fmt.Errorf("problem applying the patch: %v", err)
+	}
+
+	return returned.Trigger, nil
+}
+
+// mergePatch applies a JSON merge patch (RFC 7396) to a decoded JSON document: objects in the
+// patch are merged into the target, nulls remove the member, and everything else replaces it
+func mergePatch(target, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = make(map[string]any)
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+		} else {
+			targetObject[key] = mergePatch(targetObject[key], value)
+		}
+	}
+
+	return targetObject
+}
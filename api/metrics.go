@@ -0,0 +1,32 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// GetDeliveryMetrics godoc
+// @Summary Get webhook delivery metrics
+// @Description Get webhook delivery statistics (per URL) since the service started
+// @Tags metrics
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} api.SystemResponse
+// @Security ApiKeyAuth
+// @Router /metrics/deliveries [get]
+func (service Service) GetDeliveryMetrics(rw http.ResponseWriter, req *http.Request) {
+
+	//	Get the current statistics
+	retval := service.Metrics.Snapshot()
+
+	//	Construct our response
+	response := SystemResponse{
+		Message: fmt.Sprintf("%v webhook url(s)", len(retval)),
+		Data:    retval,
+	}
+
+	//	Serialize to JSON & return the response:
+	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(rw).Encode(response)
+}
@@ -2,7 +2,10 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"github.com/danesparza/fxtrigger/internal/data"
+	"github.com/danesparza/fxtrigger/internal/gpio"
+	"github.com/danesparza/fxtrigger/internal/trigger"
 	"net/http"
 	"time"
 )
@@ -12,34 +15,74 @@ type Service struct {
 	DB        *data.Manager
 	StartTime time.Time
 
-	// FireTrigger signals a trigger should be fired
-	FireTrigger chan data.Trigger
+	// Metrics tracks webhook delivery statistics
+	Metrics *trigger.DeliveryMetrics
 
-	// AddMonitor signals a trigger should be added to the list of monitored triggers
-	AddMonitor chan data.Trigger
+	// Pins tracks which triggers are using each GPIO pin
+	Pins *trigger.PinRegistry
 
-	// RemoveMonitor signals a trigger id should not be monitored anymore
-	RemoveMonitor chan string
+	// Sim is the simulated GPIO driver.  It's only set when using the 'sim' GPIO driver
+	Sim *gpio.SimDriver
+
+	// FireQueue holds the trigger fires waiting to be processed
+	FireQueue *trigger.FireQueue
+
+	// Reconcile signals the monitors should be brought in line with the stored triggers
+	Reconcile chan trigger.ReconcileRequest
 }
 
 // CreateTriggerRequest is a request to create a new trigger
 type CreateTriggerRequest struct {
-	Name                          string         `json:"name"`                          // The trigger name
-	Description                   string         `json:"description"`                   // Additional information about the trigger
-	GPIOPin                       int            `json:"gpiopin"`                       // The GPIO pin the sensor or button is on
-	WebHooks                      []data.WebHook `json:"webhooks"`                      // The webhooks to send when triggered
-	MinimumSecondsBeforeRetrigger int            `json:"minimumsecondsbeforeretrigger"` // Minimum time (in seconds) before a retrigger
+	Name                          string                    `json:"name"`                          // The trigger name
+	Description                   string                    `json:"description"`                   // Additional information about the trigger
+	Type                          string                    `json:"type"`                          // The trigger type: Motion, Button, Time or System.  Defaults to Motion
+	GPIOPin                       int                       `json:"gpiopin"`                       // The GPIO pin the sensor or button is on
+	Pull                          string                    `json:"pull"`                          // The internal pull resistor for the pin: up, down or off
+	ActiveLow                     bool                      `json:"activelow"`                     // If true, the pin is active when it reads low
+	SampleIntervalMillis          int                       `json:"sampleintervalms"`              // If set, the pin is sampled at this interval (in milliseconds) instead of using edge detection
+	DebounceMillis                int                       `json:"debouncems"`                    // If set, a pin change only counts once the pin has stayed at the new level for this long (in milliseconds)
+	Edge                          string                    `json:"edge"`                          // The pin edge(s) that fire the trigger: rising, falling or both.  Defaults to rising
+	LongPressMillis               int                       `json:"longpressms"`                   // Button triggers: how long (in milliseconds) the button is held before it's a long press
+	DoublePressMillis             int                       `json:"doublepressms"`                 // Button triggers: how long (in milliseconds) to wait for a second press
+	HoldRepeatMillis              int                       `json:"holdrepeatms"`                  // Button triggers: how often (in milliseconds) the hold event repeats while the button is held
+	Schedule                      string                    `json:"schedule"`                      // Time triggers: the cron expression for when the trigger fires (like '30 7 * * 1-5' or '@hourly')
+	Timezone                      string                    `json:"timezone"`                      // Time triggers: the timezone for the schedule (like 'America/New_York').  Defaults to the local timezone
+	SystemEvent                   string                    `json:"systemevent"`                   // System triggers: the system event that fires the trigger: startup, shutdown, networkup, monitorfailure or deliveryfailure
+	WebHooks                      []data.WebHook            `json:"webhooks"`                      // The webhooks to send when triggered
+	EventWebHooks                 map[string][]data.WebHook `json:"eventwebhooks"`                 // Webhooks to send for specific events (like 'deactivated') instead of the trigger webhooks
+	MinimumSecondsBeforeRetrigger int                       `json:"minimumsecondsbeforeretrigger"` // Minimum time (in seconds) before a retrigger
+	IgnoreDND                     bool                      `json:"ignorednd"`                     // If true, the trigger fires even when do not disturb is active
 }
 
 // UpdateTriggerRequest is a request to update a trigger
 type UpdateTriggerRequest struct {
-	ID                            string         `json:"id"`                            // Unique Trigger ID
-	Enabled                       bool           `json:"enabled"`                       // Trigger enabled or not
-	Name                          string         `json:"name"`                          // The trigger name
-	Description                   string         `json:"description"`                   // Additional information about the trigger
-	GPIOPin                       int            `json:"gpiopin"`                       // The GPIO pin the sensor or button is on
-	WebHooks                      []data.WebHook `json:"webhooks"`                      // The webhooks to send when triggered
-	MinimumSecondsBeforeRetrigger int            `json:"minimumsecondsbeforeretrigger"` // Minimum time (in seconds) before a retrigger
+	ID                            string                    `json:"id"`                            // Unique Trigger ID
+	Enabled                       bool                      `json:"enabled"`                       // Trigger enabled or not
+	Name                          string                    `json:"name"`                          // The trigger name
+	Description                   string                    `json:"description"`                   // Additional information about the trigger
+	Type                          string                    `json:"type"`                          // The trigger type: Motion, Button, Time or System.  Defaults to Motion
+	GPIOPin                       int                       `json:"gpiopin"`                       // The GPIO pin the sensor or button is on
+	Pull                          string                    `json:"pull"`                          // The internal pull resistor for the pin: up, down or off
+	ActiveLow                     bool                      `json:"activelow"`                     // If true, the pin is active when it reads low
+	SampleIntervalMillis          int                       `json:"sampleintervalms"`              // If set, the pin is sampled at this interval (in milliseconds) instead of using edge detection
+	DebounceMillis                int                       `json:"debouncems"`                    // If set, a pin change only counts once the pin has stayed at the new level for this long (in milliseconds)
+	Edge                          string                    `json:"edge"`                          // The pin edge(s) that fire the trigger: rising, falling or both
+	LongPressMillis               int                       `json:"longpressms"`                   // Button triggers: how long (in milliseconds) the button is held before it's a long press
+	DoublePressMillis             int                       `json:"doublepressms"`                 // Button triggers: how long (in milliseconds) to wait for a second press
+	HoldRepeatMillis              int                       `json:"holdrepeatms"`                  // Button triggers: how often (in milliseconds) the hold event repeats while the button is held
+	Schedule                      string                    `json:"schedule"`                      // Time triggers: the cron expression for when the trigger fires (like '30 7 * * 1-5' or '@hourly')
+	Timezone                      string                    `json:"timezone"`                      // Time triggers: the timezone for the schedule (like 'America/New_York').  Defaults to the local timezone
+	SystemEvent                   string                    `json:"systemevent"`                   // System triggers: the system event that fires the trigger: startup, shutdown, networkup, monitorfailure or deliveryfailure
+	WebHooks                      []data.WebHook            `json:"webhooks"`                      // The webhooks to send when triggered
+	EventWebHooks                 map[string][]data.WebHook `json:"eventwebhooks"`                 // Webhooks to send for specific events (like 'deactivated') instead of the trigger webhooks
+	MinimumSecondsBeforeRetrigger int                       `json:"minimumsecondsbeforeretrigger"` // Minimum time (in seconds) before a retrigger
+	IgnoreDND                     bool                      `json:"ignorednd"`                     // If true, the trigger fires even when do not disturb is active
+}
+
+// TriggerResponse is a trigger, along with when it fires next.  Webhook signing secrets aren't included
+type TriggerResponse struct {
+	data.Trigger
+	NextFireTime *time.Time `json:"nextfiretime,omitempty"` // Time triggers: the next time the trigger fires (if it's enabled)
 }
 
 // SystemResponse is a response for a system request
@@ -50,7 +93,8 @@ type SystemResponse struct {
 
 // ErrorResponse represents an API response
 type ErrorResponse struct {
-	Message string `json:"message"`
+	Message string       `json:"message"`
+	Errors  []FieldError `json:"errors,omitempty"` // Each invalid field (for invalid requests)
 }
 
 // Used to send back an error:
@@ -59,6 +103,12 @@ func sendErrorResponse(rw http.ResponseWriter, err error, code int) {
 	response := ErrorResponse{
 		Message: "Error: " + err.Error()}
 
+	//	List each invalid field (if that's the problem)
+	var invalid ValidationError
+	if errors.As(err, &invalid) {
+		response.Errors = invalid.Fields
+	}
+
 	//	Serialize to JSON & return the response:
 	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
 	rw.WriteHeader(code)
@@ -0,0 +1,131 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/danesparza/fxtrigger/api"
+	"github.com/danesparza/fxtrigger/internal/data"
+	"github.com/danesparza/fxtrigger/internal/gpio"
+	"github.com/danesparza/fxtrigger/internal/trigger"
+	"github.com/gorilla/mux"
+)
+
+// simHarness runs the api and background services against the simulated GPIO driver
+type simHarness struct {
+	router  *mux.Router
+	service api.Service
+	hooks   chan *http.Request
+	hookTS  *httptest.Server
+}
+
+func newSimHarness(t *testing.T) *simHarness {
+	t.Helper()
+
+	db, err := data.NewManager(filepath.Join(t.TempDir(), "system.db"))
+	if err != nil {
+		t.Fatalf("NewManager failed: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(func() {
+		cancel()
+		db.Close()
+	})
+
+	sim := gpio.NewSimDriver()
+
+	backgroundService := trigger.BackgroundProcess{
+		FireQueue: trigger.NewFireQueue(trigger.FireQueueOptions{DB: db}),
+		Reconcile: make(chan trigger.ReconcileRequest),
+		DB:        db,
+		GPIO:      sim,
+		Metrics:   trigger.NewDeliveryMetrics(),
+		Pins:      trigger.NewPinRegistry(false),
+	}
+
+	apiService := api.Service{
+		FireQueue: backgroundService.FireQueue,
+		Reconcile: backgroundService.Reconcile,
+		DB:        db,
+		Metrics:   backgroundService.Metrics,
+		Pins:      backgroundService.Pins,
+		Sim:       sim,
+		StartTime: time.Now(),
+	}
+
+	go backgroundService.ListenForEvents(ctx)
+	go backgroundService.HandleAndProcess(ctx)
+
+	h := &simHarness{router: mux.NewRouter(), service: apiService, hooks: make(chan *http.Request, 10)}
+	h.router.HandleFunc("/v1/triggers", apiService.CreateTrigger).Methods("POST")
+	h.router.HandleFunc("/v1/triggers", apiService.ListAllTriggers).Methods("GET")
+	h.router.HandleFunc("/v1/triggers", apiService.UpdateTrigger).Methods("PUT")
+	h.router.HandleFunc("/v1/triggers/{id}", apiService.GetTrigger).Methods("GET")
+	h.router.HandleFunc("/v1/triggers/{id}", apiService.UpdateTrigger).Methods("PUT")
+	h.router.HandleFunc("/v1/triggers/{id}", apiService.PatchTrigger).Methods("PATCH")
+	h.router.HandleFunc("/v1/triggers/{id}/enable", apiService.EnableTrigger).Methods("POST")
+	h.router.HandleFunc("/v1/triggers/{id}/disable", apiService.DisableTrigger).Methods("POST")
+	h.router.HandleFunc("/v1/triggers/{id}/history", apiService.ListTriggerHistory).Methods("GET")
+	h.router.HandleFunc("/v1/trigger/fire/{id}", apiService.FireSingleTrigger).Methods("POST")
+	h.router.HandleFunc("/v1/history", apiService.ListAllHistory).Methods("GET")
+	h.router.HandleFunc("/v1/metrics/deliveries", apiService.GetDeliveryMetrics).Methods("GET")
+	h.router.HandleFunc("/v1/status", apiService.GetStatus).Methods("GET")
+	h.router.HandleFunc("/v1/sim/pins/{pin}", apiService.SetSimPin).Methods("POST")
+	h.router.HandleFunc("/v1/dnd", apiService.GetDND).Methods("GET")
+	h.router.HandleFunc("/v1/dnd", apiService.UpdateDND).Methods("PUT")
+	h.router.HandleFunc("/v1/keys", apiService.CreateAPIKey).Methods("POST")
+	h.router.HandleFunc("/v1/keys", apiService.ListAllAPIKeys).Methods("GET")
+	h.router.HandleFunc("/v1/keys/{id}", apiService.DeleteAPIKey).Methods("DELETE")
+
+	h.hookTS = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		h.hooks <- req
+		rw.Write([]byte("received"))
+	}))
+	t.Cleanup(h.hookTS.Close)
+
+	return h
+}
+
+// call sends a request to the api and returns the response recorder
+func (h *simHarness) call(method, url string, body any) *httptest.ResponseRecorder {
+	encoded, _ := json.Marshal(body)
+	rr := httptest.NewRecorder()
+	h.router.ServeHTTP(rr, httptest.NewRequest(method, url, bytes.NewReader(encoded)))
+	return rr
+}
+
+// callWithKey sends a request from the remote address through the authentication middleware, with the API key
+// (if it's set), and returns the response recorder
+func (h *simHarness) callWithKey(method, url, remoteAddr, key string, body any) *httptest.ResponseRecorder {
+	encoded, _ := json.Marshal(body)
+	req := httptest.NewRequest(method, url, bytes.NewReader(encoded))
+	req.RemoteAddr = remoteAddr
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+
+	rr := httptest.NewRecorder()
+	h.service.Authenticate(h.router).ServeHTTP(rr, req)
+	return rr
+}
+
+// hookCount counts the webhooks received within the wait time
+func (h *simHarness) hookCount(wait time.Duration) int {
+	count := 0
+	timeout := time.After(wait)
+	for {
+		select {
+		case <-h.hooks:
+			count++
+		case <-timeout:
+			return count
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danesparza/fxtrigger/internal/gpio"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// SetSimPinRequest is a request to change the level of a simulated GPIO pin
+type SetSimPinRequest struct {
+	Action      string `json:"action"`      // The action to take: high, low or pulse
+	PulseMillis int    `json:"pulsemillis"` // How long (in milliseconds) to hold the pin high when pulsing
+}
+
+// SimPinResponse is the state of a simulated GPIO pin
+type SimPinResponse struct {
+	GPIOPin int    `json:"gpiopin"` // The GPIO pin
+	Level   string `json:"level"`   // The current level: high or low
+}
+
+// GetSimPin godoc
+// @Summary Gets the level of a simulated GPIO pin
+// @Description Gets the level of a simulated GPIO pin.  Only available when using the sim GPIO driver
+// @Tags sim
+// @Accept  json
+// @Produce  json
+// @Param pin path int true "The GPIO pin"
+// @Success 200 {object} api.SystemResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /sim/pins/{pin} [get]
+func (service Service) GetSimPin(rw http.ResponseWriter, req *http.Request) {
+
+	//	Get the pin from the url
+	pin, err := strconv.Atoi(mux.Vars(req)["pin"])
+	if err != nil {
+		sendErrorResponse(rw, fmt.Errorf("requires a numeric GPIO pin: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	//	Construct our response
+	response := SystemResponse{
+		Message: "Simulated pin level",
+		Data:    SimPinResponse{GPIOPin: pin, Level: service.Sim.Read(pin).String()},
+	}
+
+	//	Serialize to JSON & return the response:
+	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(rw).Encode(response)
+}
+
+// SetSimPin godoc
+// @Summary Sets the level of a simulated GPIO pin
+// @Description Sets a simulated GPIO pin high or low, or pulses it high for pulsemillis.  Only available when using the sim GPIO driver
+// @Tags sim
+// @Accept  json
+// @Produce  json
+// @Param pin path int true "The GPIO pin"
+// @Param request body api.SetSimPinRequest true "The action to take"
+// @Success 200 {object} api.SystemResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /sim/pins/{pin} [post]
+func (service Service) SetSimPin(rw http.ResponseWriter, req *http.Request) {
+
+	//	req.Body is a ReadCloser -- we need to remember to close it:
+	defer req.Body.Close()
+
+	//	Get the pin from the url
+	pin, err := strconv.Atoi(mux.Vars(req)["pin"])
+	if err != nil {
+		sendErrorResponse(rw, fmt.Errorf("requires a numeric GPIO pin: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	//	Decode the request
+	request := SetSimPinRequest{}
+	err = json.NewDecoder(req.Body).Decode(&request)
+	if err != nil {
+		sendErrorResponse(rw, err, http.StatusBadRequest)
+		return
+	}
+
+	switch strings.ToLower(request.Action) {
+	case "high":
+		service.Sim.Set(pin, gpio.High)
+	case "low":
+		service.Sim.Set(pin, gpio.Low)
+	case "pulse":
+		if request.PulseMillis < 1 {
+			sendErrorResponse(rw, fmt.Errorf("pulsemillis must be greater than zero when pulsing"), http.StatusBadRequest)
+			return
+		}
+		service.Sim.Pulse(pin, time.Duration(request.PulseMillis)*time.Millisecond)
+	default:
+		sendErrorResponse(rw, fmt.Errorf("action must be one of: high, low, pulse"), http.StatusBadRequest)
+		return
+	}
+
+	//	Record the event:
+	log.Debug().Int("GPIOPin", pin).Str("action", request.Action).Int("pulsemillis", request.PulseMillis).Msg("Simulated pin set")
+
+	//	Construct our response
+	response := SystemResponse{
+		Message: "Simulated pin set",
+		Data:    SimPinResponse{GPIOPin: pin, Level: service.Sim.Read(pin).String()},
+	}
+
+	//	Serialize to JSON & return the response:
+	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(rw).Encode(response)
+}
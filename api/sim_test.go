@@ -0,0 +1,360 @@
+package api_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/danesparza/fxtrigger/api"
+	"github.com/danesparza/fxtrigger/internal/data"
+)
+
+func TestSim_SetSimPin_PulseFiresTrigger_Successful(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+	rr := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:     "Sim trigger",
+		GPIOPin:  17,
+		WebHooks: []data.WebHook{{URL: h.hookTS.URL}},
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("CreateTrigger failed: %v %s", rr.Code, rr.Body.String())
+	}
+
+	//	Act
+	rr = h.call(http.MethodPost, "/v1/sim/pins/17", api.SetSimPinRequest{Action: "pulse", PulseMillis: 1000})
+
+	//	Assert
+	if rr.Code != http.StatusOK {
+		t.Fatalf("SetSimPin failed: %v %s", rr.Code, rr.Body.String())
+	}
+
+	if count := h.hookCount(2 * time.Second); count != 1 {
+		t.Errorf("SetSimPin failed: Should have fired the webhook once but got: %v", count)
+	}
+}
+
+func TestSim_SetSimPin_RetriggerTooSoon_FiresOnce(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+	rr := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:                          "Sim trigger",
+		GPIOPin:                       17,
+		WebHooks:                      []data.WebHook{{URL: h.hookTS.URL}},
+		MinimumSecondsBeforeRetrigger: 60,
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("CreateTrigger failed: %v %s", rr.Code, rr.Body.String())
+	}
+
+	//	Act
+	h.call(http.MethodPost, "/v1/sim/pins/17", api.SetSimPinRequest{Action: "high"})
+	time.Sleep(1 * time.Second)
+	h.call(http.MethodPost, "/v1/sim/pins/17", api.SetSimPinRequest{Action: "low"})
+	time.Sleep(1 * time.Second)
+	h.call(http.MethodPost, "/v1/sim/pins/17", api.SetSimPinRequest{Action: "high"})
+
+	//	Assert
+	if count := h.hookCount(2 * time.Second); count != 1 {
+		t.Errorf("SetSimPin failed: Should have fired the webhook once but got: %v", count)
+	}
+}
+
+func TestSim_SetSimPin_InvalidAction_BadRequest(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+
+	//	Act
+	rr := h.call(http.MethodPost, "/v1/sim/pins/17", api.SetSimPinRequest{Action: "sideways"})
+
+	//	Assert
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("SetSimPin failed: Should have returned a bad request but got: %v", rr.Code)
+	}
+}
+
+func TestSim_SetSimPin_BothEdges_FiresEventWebHooks(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+	rr := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:          "Sim trigger",
+		GPIOPin:       17,
+		Edge:          "both",
+		WebHooks:      []data.WebHook{{URL: h.hookTS.URL + "/started"}},
+		EventWebHooks: map[string][]data.WebHook{"deactivated": {{URL: h.hookTS.URL + "/stopped"}}},
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("CreateTrigger failed: %v %s", rr.Code, rr.Body.String())
+	}
+
+	//	Act
+	h.call(http.MethodPost, "/v1/sim/pins/17", api.SetSimPinRequest{Action: "pulse", PulseMillis: 1000})
+
+	//	Assert
+	for _, expected := range []string{"/started", "/stopped"} {
+		select {
+		case req := <-h.hooks:
+			if req.URL.Path != expected {
+				t.Errorf("SetSimPin failed: Should have sent %v but got: %v", expected, req.URL.Path)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("SetSimPin failed: Timed out waiting for %v", expected)
+		}
+	}
+}
+
+func TestSim_SetSimPin_FallingEdge_FiresOnRelease(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+	rr := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:     "Sim trigger",
+		GPIOPin:  17,
+		Edge:     "falling",
+		WebHooks: []data.WebHook{{URL: h.hookTS.URL}},
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("CreateTrigger failed: %v %s", rr.Code, rr.Body.String())
+	}
+
+	//	Act
+	h.call(http.MethodPost, "/v1/sim/pins/17", api.SetSimPinRequest{Action: "high"})
+	firedWhileHigh := h.hookCount(1 * time.Second)
+	h.call(http.MethodPost, "/v1/sim/pins/17", api.SetSimPinRequest{Action: "low"})
+	firedOnRelease := h.hookCount(1 * time.Second)
+
+	//	Assert
+	if firedWhileHigh != 0 || firedOnRelease != 1 {
+		t.Errorf("SetSimPin failed: Should only fire on the falling edge but got: %v (high), %v (low)", firedWhileHigh, firedOnRelease)
+	}
+}
+
+func TestSim_SetSimPin_ActiveLowPullUp_FiresOnPress(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+	rr := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:      "Sim button",
+		GPIOPin:   22,
+		Pull:      "up",
+		ActiveLow: true,
+		WebHooks:  []data.WebHook{{URL: h.hookTS.URL}},
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("CreateTrigger failed: %v %s", rr.Code, rr.Body.String())
+	}
+
+	//	Act
+	firedAtRest := h.hookCount(1 * time.Second)
+	h.call(http.MethodPost, "/v1/sim/pins/22", api.SetSimPinRequest{Action: "low"})
+	firedOnPress := h.hookCount(1 * time.Second)
+
+	//	Assert
+	if firedAtRest != 0 || firedOnPress != 1 {
+		t.Errorf("SetSimPin failed: Should only fire when pulled low but got: %v (at rest), %v (pressed)", firedAtRest, firedOnPress)
+	}
+}
+
+func TestSim_SetSimPin_QuickTap_FiresTrigger(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+	rr := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:     "Sim button",
+		GPIOPin:  17,
+		WebHooks: []data.WebHook{{URL: h.hookTS.URL}},
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("CreateTrigger failed: %v %s", rr.Code, rr.Body.String())
+	}
+
+	//	Act
+	h.call(http.MethodPost, "/v1/sim/pins/17", api.SetSimPinRequest{Action: "pulse", PulseMillis: 1})
+
+	//	Assert
+	if count := h.hookCount(1 * time.Second); count != 1 {
+		t.Errorf("SetSimPin failed: Should have caught the quick tap but got: %v", count)
+	}
+}
+
+func TestSim_SetSimPin_SampleIntervalFallback_FiresTrigger(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+	rr := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:                 "Sim sensor",
+		GPIOPin:              17,
+		SampleIntervalMillis: 100,
+		WebHooks:             []data.WebHook{{URL: h.hookTS.URL}},
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("CreateTrigger failed: %v %s", rr.Code, rr.Body.String())
+	}
+
+	//	Act
+	h.call(http.MethodPost, "/v1/sim/pins/17", api.SetSimPinRequest{Action: "pulse", PulseMillis: 300})
+
+	//	Assert
+	if count := h.hookCount(1 * time.Second); count != 1 {
+		t.Errorf("SetSimPin failed: Should have sampled the pulse but got: %v", count)
+	}
+}
+
+func TestSim_SetSimPin_DebouncedChatter_FiresOnce(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+	rr := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:           "Sim button",
+		GPIOPin:        17,
+		DebounceMillis: 200,
+		WebHooks:       []data.WebHook{{URL: h.hookTS.URL}},
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("CreateTrigger failed: %v %s", rr.Code, rr.Body.String())
+	}
+
+	//	Act
+	for _, action := range []string{"high", "low", "high", "low", "high"} {
+		h.call(http.MethodPost, "/v1/sim/pins/17", api.SetSimPinRequest{Action: action})
+		time.Sleep(30 * time.Millisecond)
+	}
+
+	//	Assert
+	if count := h.hookCount(1 * time.Second); count != 1 {
+		t.Errorf("SetSimPin failed: Should have fired the webhook once but got: %v", count)
+	}
+}
+
+func TestSim_SetSimPin_DebouncedGlitch_DoesNotFire(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+	rr := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:           "Sim button",
+		GPIOPin:        17,
+		DebounceMillis: 200,
+		WebHooks:       []data.WebHook{{URL: h.hookTS.URL}},
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("CreateTrigger failed: %v %s", rr.Code, rr.Body.String())
+	}
+
+	//	Act
+	h.call(http.MethodPost, "/v1/sim/pins/17", api.SetSimPinRequest{Action: "pulse", PulseMillis: 50})
+
+	//	Assert
+	if count := h.hookCount(1 * time.Second); count != 0 {
+		t.Errorf("SetSimPin failed: Should have ignored the glitch but got: %v", count)
+	}
+}
+
+func TestSim_SetSimPin_ButtonDoublePress_FiresDoublePressWebHooks(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+	rr := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:              "Sim button",
+		Type:              "Button",
+		GPIOPin:           17,
+		DoublePressMillis: 400,
+		WebHooks:          []data.WebHook{{URL: h.hookTS.URL + "/press"}},
+		EventWebHooks:     map[string][]data.WebHook{"doublepress": {{URL: h.hookTS.URL + "/doublepress"}}},
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("CreateTrigger failed: %v %s", rr.Code, rr.Body.String())
+	}
+
+	//	Act
+	h.call(http.MethodPost, "/v1/sim/pins/17", api.SetSimPinRequest{Action: "pulse", PulseMillis: 50})
+	time.Sleep(150 * time.Millisecond)
+	h.call(http.MethodPost, "/v1/sim/pins/17", api.SetSimPinRequest{Action: "pulse", PulseMillis: 50})
+
+	//	Assert
+	select {
+	case req := <-h.hooks:
+		if req.URL.Path != "/doublepress" {
+			t.Errorf("SetSimPin failed: Should have sent /doublepress but got: %v", req.URL.Path)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("SetSimPin failed: Timed out waiting for /doublepress")
+	}
+
+	if count := h.hookCount(1 * time.Second); count != 0 {
+		t.Errorf("SetSimPin failed: Should only have sent the double press but got %v more", count)
+	}
+}
+
+func TestSim_SetSimPin_ButtonSinglePress_FiresPressAfterWindow(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+	rr := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:              "Sim button",
+		Type:              "Button",
+		GPIOPin:           17,
+		DoublePressMillis: 200,
+		WebHooks:          []data.WebHook{{URL: h.hookTS.URL + "/press"}},
+		EventWebHooks:     map[string][]data.WebHook{"doublepress": {{URL: h.hookTS.URL + "/doublepress"}}},
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("CreateTrigger failed: %v %s", rr.Code, rr.Body.String())
+	}
+
+	//	Act
+	h.call(http.MethodPost, "/v1/sim/pins/17", api.SetSimPinRequest{Action: "pulse", PulseMillis: 50})
+
+	//	Assert
+	select {
+	case req := <-h.hooks:
+		if req.URL.Path != "/press" {
+			t.Errorf("SetSimPin failed: Should have sent /press but got: %v", req.URL.Path)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("SetSimPin failed: Timed out waiting for /press")
+	}
+}
+
+func TestSim_SetSimPin_ButtonLongPressAndHold_FiresGestureWebHooks(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+	rr := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:             "Sim button",
+		Type:             "Button",
+		GPIOPin:          17,
+		LongPressMillis:  200,
+		HoldRepeatMillis: 200,
+		WebHooks:         []data.WebHook{{URL: h.hookTS.URL + "/press"}},
+		EventWebHooks: map[string][]data.WebHook{
+			"longpress": {{URL: h.hookTS.URL + "/longpress"}},
+			"hold":      {{URL: h.hookTS.URL + "/hold"}},
+		},
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("CreateTrigger failed: %v %s", rr.Code, rr.Body.String())
+	}
+
+	//	Act
+	h.call(http.MethodPost, "/v1/sim/pins/17", api.SetSimPinRequest{Action: "pulse", PulseMillis: 500})
+
+	//	Assert
+	for _, expected := range []string{"/longpress", "/hold"} {
+		select {
+		case req := <-h.hooks:
+			if req.URL.Path != expected {
+				t.Errorf("SetSimPin failed: Should have sent %v but got: %v", expected, req.URL.Path)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("SetSimPin failed: Timed out waiting for %v", expected)
+		}
+	}
+
+	if count := h.hookCount(1 * time.Second); count != 0 {
+		t.Errorf("SetSimPin failed: Releasing a long press shouldn't send anything, but got %v more", count)
+	}
+}
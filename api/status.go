@@ -0,0 +1,45 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/danesparza/fxtrigger/internal/trigger"
+)
+
+// StatusResponse is the current state of the service
+type StatusResponse struct {
+	StartTime     time.Time           `json:"starttime"`     // When the service started
+	UptimeSeconds int64               `json:"uptimeseconds"` // How long the service has been running
+	FireQueue     trigger.QueueStatus `json:"firequeue"`     // The trigger fires waiting to be delivered
+}
+
+// GetStatus godoc
+// @Summary Get the service status
+// @Description Get the current state of the service, including the depth of the fire queue
+// @Tags status
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} api.SystemResponse
+// @Security ApiKeyAuth
+// @Router /status [get]
+func (service Service) GetStatus(rw http.ResponseWriter, req *http.Request) {
+
+	//	Get the current state
+	retval := StatusResponse{
+		StartTime:     service.StartTime,
+		UptimeSeconds: int64(time.Since(service.StartTime).Seconds()),
+		FireQueue:     service.FireQueue.Status(),
+	}
+
+	//	Construct our response
+	response := SystemResponse{
+		Message: "Status",
+		Data:    retval,
+	}
+
+	//	Serialize to JSON & return the response:
+	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(rw).Encode(response)
+}
@@ -0,0 +1,30 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/danesparza/fxtrigger/api"
+)
+
+func TestStatus_GetStatus_IncludesFireQueue(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+
+	//	Act
+	rr := h.call(http.MethodGet, "/v1/status", nil)
+
+	//	Assert
+	response := struct {
+		Data api.StatusResponse `json:"data"`
+	}{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("GetStatus failed: %s", err)
+	}
+
+	if response.Data.FireQueue.Capacity == 0 || response.Data.FireQueue.Workers == 0 {
+		t.Errorf("GetStatus failed: Should have included the fire queue but got: %+v", response.Data.FireQueue)
+	}
+}
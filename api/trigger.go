@@ -3,9 +3,15 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"github.com/danesparza/fxtrigger/internal/data"
+	"github.com/danesparza/fxtrigger/internal/trigger"
+	"github.com/danesparza/fxtrigger/internal/triggerevent"
+	"github.com/danesparza/fxtrigger/internal/triggersource"
+	"github.com/danesparza/fxtrigger/internal/triggertype"
 	"github.com/rs/zerolog/log"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -18,6 +24,7 @@ import (
 // @Produce  json
 // @Success 200 {object} api.SystemResponse
 // @Failure 500 {object} api.ErrorResponse
+// @Security ApiKeyAuth
 // @Router /triggers [get]
 func (service Service) ListAllTriggers(rw http.ResponseWriter, req *http.Request) {
 
@@ -29,10 +36,16 @@ func (service Service) ListAllTriggers(rw http.ResponseWriter, req *http.Request
 		return
 	}
 
+	//	Include when each trigger fires next
+	triggers := make([]TriggerResponse, 0, len(retval))
+	for _, t := range retval {
+		triggers = append(triggers, newTriggerResponse(t))
+	}
+
 	//	Construct our response
 	response := SystemResponse{
 		Message: fmt.Sprintf("%v triggers(s)", len(retval)),
-		Data:    retval,
+		Data:    triggers,
 	}
 
 	//	Serialize to JSON & return the response:
@@ -49,7 +62,9 @@ func (service Service) ListAllTriggers(rw http.ResponseWriter, req *http.Request
 // @Param trigger body api.CreateTriggerRequest true "The trigger to create"
 // @Success 200 {object} api.SystemResponse
 // @Failure 400 {object} api.ErrorResponse
+// @Failure 409 {object} api.ErrorResponse
 // @Failure 500 {object} api.ErrorResponse
+// @Security ApiKeyAuth
 // @Router /triggers [post]
 func (service Service) CreateTrigger(rw http.ResponseWriter, req *http.Request) {
 
@@ -64,29 +79,96 @@ func (service Service) CreateTrigger(rw http.ResponseWriter, req *http.Request)
 		return
 	}
 
-	//	If we don't have any webhooks associated, make sure we indicate that's not valid
-	if len(request.WebHooks) < 1 {
-		sendErrorResponse(rw, fmt.Errorf("at least one webhook must be included"), http.StatusBadRequest)
+	newTrigger := data.Trigger{
+		Name:                          request.Name,
+		Description:                   request.Description,
+		Type:                          request.Type,
+		Schedule:                      request.Schedule,
+		Timezone:                      request.Timezone,
+		SystemEvent:                   request.SystemEvent,
+		GPIOPin:                       request.GPIOPin,
+		Pull:                          request.Pull,
+		ActiveLow:                     request.ActiveLow,
+		SampleIntervalMillis:          request.SampleIntervalMillis,
+		DebounceMillis:                request.DebounceMillis,
+		Edge:                          request.Edge,
+		LongPressMillis:               request.LongPressMillis,
+		DoublePressMillis:             request.DoublePressMillis,
+		HoldRepeatMillis:              request.HoldRepeatMillis,
+		WebHooks:                      request.WebHooks,
+		EventWebHooks:                 request.EventWebHooks,
+		MinimumSecondsBeforeRetrigger: request.MinimumSecondsBeforeRetrigger,
+		IgnoreDND:                     request.IgnoreDND,
+	}
+
+	//	Triggers without a type are motion sensors
+	if strings.TrimSpace(newTrigger.Type) == "" {
+		newTrigger.Type = triggertype.Motion
+	}
+
+	//	Make sure the trigger settings are valid (for the type of trigger)
+	if err := validateTrigger(newTrigger); err != nil {
+		sendErrorResponse(rw, err, http.StatusBadRequest)
+		return
+	}
+
+	//	Make sure the pin isn't reserved or used by another trigger with different settings
+	if err := service.checkPin(newTrigger); err != nil {
+		sendErrorResponse(rw, err, http.StatusConflict)
 		return
 	}
 
 	//	Create the new trigger:
-	newTrigger, err := service.DB.AddTrigger(request.Name, request.Description, request.GPIOPin, request.WebHooks, request.MinimumSecondsBeforeRetrigger)
+	newTrigger, err = service.DB.CreateTrigger(newTrigger)
 	if err != nil {
 		sendErrorResponse(rw, err, http.StatusInternalServerError)
 		return
 	}
 
 	//	Record the event:
-	log.Debug().Any("request", request).Msg("Trigger created")
+	log.Debug().Str("id", newTrigger.ID).Str("name", newTrigger.Name).Msg("Trigger created")
 
 	//	Add the new trigger to monitoring:
-	service.AddMonitor <- newTrigger
+	service.reconcile(newTrigger.ID)
 
 	//	Create our response and send information back:
 	response := SystemResponse{
 		Message: "Trigger created",
-		Data:    newTrigger,
+		Data:    newTriggerResponse(newTrigger),
+	}
+
+	//	Serialize to JSON & return the response:
+	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(rw).Encode(response)
+}
+
+// GetTrigger godoc
+// @Summary Get a single trigger
+// @Description Get a single trigger
+// @Tags triggers
+// @Accept  json
+// @Produce  json
+// @Param id path string true "The trigger id to get"
+// @Success 200 {object} api.SystemResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /triggers/{id} [get]
+func (service Service) GetTrigger(rw http.ResponseWriter, req *http.Request) {
+
+	//	Get the id from the url
+	id := mux.Vars(req)["id"]
+
+	//	Make sure the id exists
+	t, _ := service.DB.GetTrigger(id)
+	if t.ID != id {
+		sendErrorResponse(rw, fmt.Errorf("trigger %v doesn't exist", id), http.StatusNotFound)
+		return
+	}
+
+	//	Construct our response
+	response := SystemResponse{
+		Message: "Trigger",
+		Data:    newTriggerResponse(t),
 	}
 
 	//	Serialize to JSON & return the response:
@@ -95,22 +177,23 @@ func (service Service) CreateTrigger(rw http.ResponseWriter, req *http.Request)
 }
 
 // UpdateTrigger godoc
-// @Summary Update a trigger
-// @Description Update a trigger
+// @Summary Replace a trigger
+// @Description Replace all of the settings of a trigger.  Settings that aren't included are cleared.  Use PATCH to change only some settings
 // @Tags triggers
 // @Accept  json
 // @Produce  json
-// @Param trigger body api.UpdateTriggerRequest true "The trigger to update.  Must include trigger.id"
+// @Param id path string true "The trigger id to replace.  If the body also has an id, they must match"
+// @Param trigger body api.UpdateTriggerRequest true "The trigger to update.  Must include trigger.id when using /triggers"
 // @Success 200 {object} api.SystemResponse
 // @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 409 {object} api.ErrorResponse
 // @Failure 500 {object} api.ErrorResponse
+// @Security ApiKeyAuth
 // @Router /triggers [put]
+// @Router /triggers/{id} [put]
 func (service Service) UpdateTrigger(rw http.ResponseWriter, req *http.Request) {
 
-	//	Some state change instructions
-	shouldAddMonitoring := false
-	shouldRemoveMonitoring := false
-
 	//	req.Body is a ReadCloser -- we need to remember to close it:
 	defer req.Body.Close()
 
@@ -122,6 +205,15 @@ func (service Service) UpdateTrigger(rw http.ResponseWriter, req *http.Request)
 		return
 	}
 
+	//	The id can also be passed in the url (PUT /triggers/{id})
+	if id, exists := mux.Vars(req)["id"]; exists {
+		if request.ID != "" && request.ID != id {
+			sendErrorResponse(rw, fmt.Errorf("the trigger.id doesn't match the url"), http.StatusBadRequest)
+			return
+		}
+		request.ID = id
+	}
+
 	//	If we don't have the trigger.id, make sure we indicate that's not valid
 	if strings.TrimSpace(request.ID) == "" {
 		sendErrorResponse(rw, fmt.Errorf("the trigger.id is required"), http.StatusBadRequest)
@@ -129,53 +221,204 @@ func (service Service) UpdateTrigger(rw http.ResponseWriter, req *http.Request)
 	}
 
 	//	Make sure the id exists
-	trigUpdate, _ := service.DB.GetTrigger(request.ID)
-	if trigUpdate.ID != request.ID {
-		sendErrorResponse(rw, fmt.Errorf("trigger must already exist"), http.StatusBadRequest)
+	stored, _ := service.DB.GetTrigger(request.ID)
+	if stored.ID != request.ID {
+		sendErrorResponse(rw, fmt.Errorf("trigger %v doesn't exist", request.ID), http.StatusNotFound)
+		return
+	}
+
+	//	Replace everything but the id and create time
+	trigUpdate := data.Trigger{
+		ID:                            stored.ID,
+		Created:                       stored.Created,
+		Enabled:                       request.Enabled,
+		Name:                          request.Name,
+		Description:                   request.Description,
+		Type:                          request.Type,
+		Schedule:                      request.Schedule,
+		Timezone:                      request.Timezone,
+		SystemEvent:                   request.SystemEvent,
+		GPIOPin:                       request.GPIOPin,
+		Pull:                          request.Pull,
+		ActiveLow:                     request.ActiveLow,
+		SampleIntervalMillis:          request.SampleIntervalMillis,
+		DebounceMillis:                request.DebounceMillis,
+		Edge:                          request.Edge,
+		LongPressMillis:               request.LongPressMillis,
+		DoublePressMillis:             request.DoublePressMillis,
+		HoldRepeatMillis:              request.HoldRepeatMillis,
+		WebHooks:                      request.WebHooks,
+		EventWebHooks:                 request.EventWebHooks,
+		MinimumSecondsBeforeRetrigger: request.MinimumSecondsBeforeRetrigger,
+		IgnoreDND:                     request.IgnoreDND,
+	}
+
+	//	Webhooks sent back without their secrets (which aren't returned) keep them
+	trigUpdate = keepSecrets(trigUpdate, stored)
+
+	service.saveTrigger(rw, trigUpdate)
+}
+
+// PatchTrigger godoc
+// @Summary Change some settings of a trigger
+// @Description Change some settings of a trigger using a JSON merge patch (RFC 7396).  Only the settings included are changed, and settings set to null are cleared
+// @Tags triggers
+// @Accept  json
+// @Produce  json
+// @Param id path string true "The trigger id to change"
+// @Param patch body object true "The settings to change, like {\"description\": null, \"gpiopin\": 0}"
+// @Success 200 {object} api.SystemResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 409 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /triggers/{id} [patch]
+func (service Service) PatchTrigger(rw http.ResponseWriter, req *http.Request) {
+
+	//	req.Body is a ReadCloser -- we need to remember to close it:
+	defer req.Body.Close()
+
+	//	Get the id from the url
+	id := mux.Vars(req)["id"]
+
+	//	Make sure the id exists
+	stored, _ := service.DB.GetTrigger(id)
+	if stored.ID != id {
+		sendErrorResponse(rw, fmt.Errorf("trigger %v doesn't exist", id), http.StatusNotFound)
+		return
+	}
+
+	//	Decode the patch
+	var patch any
+	if err := json.NewDecoder(req.Body).Decode(&patch); err != nil {
+		sendErrorResponse(rw, err, http.StatusBadRequest)
 		return
 	}
 
-	//	See if 'enabled' has changed
-	if trigUpdate.Enabled != request.Enabled {
-		if request.Enabled {
-			//	If it has, and it's now 'enabled', add the trigger to monitoring
-			shouldAddMonitoring = true
-		} else {
-			//	If it has, and it's now 'disabled', remove the trigger from monitoring
-			shouldRemoveMonitoring = true
+	//	Apply it to the stored trigger
+	trigUpdate, err := patchTrigger(stored, patch)
+	if err != nil {
+		sendErrorResponse(rw, err, http.StatusBadRequest)
+		return
+	}
+
+	//	The id and create time can't be changed
+	trigUpdate.ID = stored.ID
+	trigUpdate.Created = stored.Created
+
+	//	Webhooks sent back without their secrets (which aren't returned) keep them
+	trigUpdate = keepSecrets(trigUpdate, stored)
+
+	service.saveTrigger(rw, trigUpdate)
+}
+
+// EnableTrigger godoc
+// @Summary Enable a trigger
+// @Description Enable a trigger, so it's monitored and fires again
+// @Tags triggers
+// @Accept  json
+// @Produce  json
+// @Param id path string true "The trigger id to enable"
+// @Success 200 {object} api.SystemResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 409 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /triggers/{id}/enable [post]
+func (service Service) EnableTrigger(rw http.ResponseWriter, req *http.Request) {
+	service.setEnabled(rw, req, true)
+}
+
+// DisableTrigger godoc
+// @Summary Disable a trigger
+// @Description Disable a trigger, so it's not monitored and doesn't fire (except with the fire endpoint)
+// @Tags triggers
+// @Accept  json
+// @Produce  json
+// @Param id path string true "The trigger id to disable"
+// @Success 200 {object} api.SystemResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Failure 500 {object} api.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /triggers/{id}/disable [post]
+func (service Service) DisableTrigger(rw http.ResponseWriter, req *http.Request) {
+	service.setEnabled(rw, req, false)
+}
+
+// setEnabled enables or disables the trigger in the url
+func (service Service) setEnabled(rw http.ResponseWriter, req *http.Request, enabled bool) {
+
+	//	Get the id from the url
+	id := mux.Vars(req)["id"]
+
+	//	Make sure the id exists
+	t, _ := service.DB.GetTrigger(id)
+	if t.ID != id {
+		sendErrorResponse(rw, fmt.Errorf("trigger %v doesn't exist", id), http.StatusNotFound)
+		return
+	}
+
+	//	If it's going to be monitored, make sure the pin isn't reserved or used by another trigger with different settings
+	t.Enabled = enabled
+	if enabled {
+		if err := service.checkPin(t); err != nil {
+			sendErrorResponse(rw, err, http.StatusConflict)
+			return
 		}
 	}
 
-	//	Only update the name if it's been passed
-	if strings.TrimSpace(request.Name) != "" {
-		trigUpdate.Name = request.Name
+	//	Save the trigger:
+	updatedTrigger, err := service.DB.UpdateTrigger(t)
+	if err != nil {
+		sendErrorResponse(rw, err, http.StatusInternalServerError)
+		return
+	}
+
+	//	Start or stop monitoring
+	service.reconcile(id)
+
+	message := "Trigger disabled"
+	if enabled {
+		message = "Trigger enabled"
 	}
+	log.Debug().Str("id", id).Msg(message)
 
-	//	Only update the description if it's been passed
-	if strings.TrimSpace(request.Description) != "" {
-		trigUpdate.Description = request.Description
+	//	Create our response and send information back:
+	response := SystemResponse{
+		Message: message,
+		Data:    newTriggerResponse(updatedTrigger),
 	}
 
-	//	Enabled / disabled is always set
-	trigUpdate.Enabled = request.Enabled
+	//	Serialize to JSON & return the response:
+	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(rw).Encode(response)
+}
+
+// saveTrigger validates and saves the new version of a stored trigger, updates
+// its monitoring and sends the response
+func (service Service) saveTrigger(rw http.ResponseWriter, trigUpdate data.Trigger) {
 
-	//	If the GPIO pin is not zero (the default value of an int) pass it in.  Yes -- GPIO 0 is valid,
-	//	but is generally reserved for special uses.  See https://pinout.xyz/pinout/pin27_gpio0#
-	if request.GPIOPin != 0 {
-		trigUpdate.GPIOPin = request.GPIOPin
+	//	Triggers without a type (from before there were types) are motion sensors
+	if strings.TrimSpace(trigUpdate.Type) == "" {
+		trigUpdate.Type = triggertype.Motion
 	}
 
-	//	This is an int. It's always going to get updated
-	trigUpdate.MinimumSecondsBeforeRetrigger = request.MinimumSecondsBeforeRetrigger
+	//	Make sure the updated trigger settings are valid (for the type of trigger)
+	if err := validateTrigger(trigUpdate); err != nil {
+		sendErrorResponse(rw, err, http.StatusBadRequest)
+		return
+	}
 
-	//	Only update webhooks if we've passed some in
-	if len(request.WebHooks) > 0 {
-		trigUpdate.WebHooks = request.WebHooks
-		service.RemoveMonitor <- trigUpdate.ID
-		shouldAddMonitoring = true
+	//	If it's going to be monitored, make sure the pin isn't reserved or used by another trigger with different settings
+	if trigUpdate.Enabled {
+		if err := service.checkPin(trigUpdate); err != nil {
+			sendErrorResponse(rw, err, http.StatusConflict)
+			return
+		}
 	}
 
-	//	Create the new trigger:
+	//	Save the trigger:
 	updatedTrigger, err := service.DB.UpdateTrigger(trigUpdate)
 	if err != nil {
 		sendErrorResponse(rw, err, http.StatusInternalServerError)
@@ -183,23 +426,15 @@ func (service Service) UpdateTrigger(rw http.ResponseWriter, req *http.Request)
 	}
 
 	//	Record the event:
-	log.Debug().Any("request", request).Msg("Trigger updated")
+	log.Debug().Str("id", updatedTrigger.ID).Str("name", updatedTrigger.Name).Msg("Trigger updated")
 
-	//	If we have a state change, make sure to add/remove monitoring and record that event as well
-	if shouldAddMonitoring {
-		service.AddMonitor <- trigUpdate
-		log.Debug().Str("id", trigUpdate.ID).Msg("Trigger monitoring enabled")
-	}
-
-	if shouldRemoveMonitoring {
-		service.RemoveMonitor <- trigUpdate.ID
-		log.Debug().Str("id", trigUpdate.ID).Msg("Trigger monitoring disabled")
-	}
+	//	Start, restart or stop monitoring (if the changes need it)
+	service.reconcile(updatedTrigger.ID)
 
 	//	Create our response and send information back:
 	response := SystemResponse{
 		Message: "Trigger updated",
-		Data:    updatedTrigger,
+		Data:    newTriggerResponse(updatedTrigger),
 	}
 
 	//	Serialize to JSON & return the response:
@@ -218,6 +453,7 @@ func (service Service) UpdateTrigger(rw http.ResponseWriter, req *http.Request)
 // @Failure 400 {object} api.ErrorResponse
 // @Failure 500 {object} api.ErrorResponse
 // @Failure 503 {object} api.ErrorResponse
+// @Security ApiKeyAuth
 // @Router /triggers/{id} [delete]
 func (service Service) DeleteTrigger(rw http.ResponseWriter, req *http.Request) {
 
@@ -241,7 +477,7 @@ func (service Service) DeleteTrigger(rw http.ResponseWriter, req *http.Request)
 	log.Debug().Str("id", vars["id"]).Msg("Trigger deleted")
 
 	//	Remove the trigger from monitoring:
-	service.RemoveMonitor <- vars["id"]
+	service.reconcile(vars["id"])
 
 	//	Construct our response
 	response := SystemResponse{
@@ -264,6 +500,8 @@ func (service Service) DeleteTrigger(rw http.ResponseWriter, req *http.Request)
 // @Success 200 {object} api.SystemResponse
 // @Failure 400 {object} api.ErrorResponse
 // @Failure 500 {object} api.ErrorResponse
+// @Failure 503 {object} api.ErrorResponse
+// @Security ApiKeyAuth
 // @Router /trigger/fire/{id} [post]
 func (service Service) FireSingleTrigger(rw http.ResponseWriter, req *http.Request) {
 
@@ -276,26 +514,115 @@ func (service Service) FireSingleTrigger(rw http.ResponseWriter, req *http.Reque
 	}
 
 	//	Get the trigger
-	trigger, err := service.DB.GetTrigger(vars["id"])
+	fireTrigger, err := service.DB.GetTrigger(vars["id"])
 	if err != nil {
 		err = fmt.Errorf("error getting trigger: %v", err)
 		sendErrorResponse(rw, err, http.StatusInternalServerError)
 		return
 	}
 
-	//	Call the channel to fire the event:
-	service.FireTrigger <- trigger
+	//	Queue the event to be fired:
+	if err := service.FireQueue.Enqueue(trigger.FireRequest{Trigger: fireTrigger, Source: triggersource.API, Event: triggerevent.Activated, Time: time.Now()}); err != nil {
+		sendErrorResponse(rw, err, http.StatusServiceUnavailable)
+		return
+	}
 
 	//	Record the event:
-	log.Debug().Str("id", trigger.ID).Str("name", trigger.Name).Msg("Trigger fired")
+	log.Debug().Str("id", fireTrigger.ID).Str("name", fireTrigger.Name).Msg("Trigger fired")
 
 	//	Construct our response
 	response := SystemResponse{
 		Message: "Trigger fired",
-		Data:    trigger,
+		Data:    newTriggerResponse(fireTrigger),
 	}
 
 	//	Serialize to JSON & return the response:
 	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
 	json.NewEncoder(rw).Encode(response)
 }
+
+// newTriggerResponse removes the webhook secrets and adds when the trigger fires next (for enabled Time triggers)
+func newTriggerResponse(t data.Trigger) TriggerResponse {
+	t.WebHooks = hideSecrets(t.WebHooks)
+
+	if len(t.EventWebHooks) > 0 {
+		eventWebHooks := make(map[string][]data.WebHook)
+		for event, hooks := range t.EventWebHooks {
+			eventWebHooks[event] = hideSecrets(hooks)
+		}
+		t.EventWebHooks = eventWebHooks
+	}
+
+	retval := TriggerResponse{Trigger: t}
+
+	if t.Type == triggertype.Time && t.Enabled {
+		if next, err := trigger.NextFireTime(t, time.Now()); err == nil && !next.IsZero() {
+			retval.NextFireTime = &next
+		}
+	}
+
+	return retval
+}
+
+// hideSecrets removes the signing secret from each webhook (and notes if it had one)
+func hideSecrets(hooks []data.WebHook) []data.WebHook {
+	retval := make([]data.WebHook, 0, len(hooks))
+	for _, hook := range hooks {
+		hook.SecretSet = hook.Secret != ""
+		hook.Secret = ""
+		retval = append(retval, hook)
+	}
+	return retval
+}
+
+// keepSecrets gives the webhooks in a changed trigger that have secretset (but no secret) the secret of the
+// saved webhook in the same position.  Since the API doesn't return secrets, this lets a trigger be sent back as-is
+func keepSecrets(t, stored data.Trigger) data.Trigger {
+	t.WebHooks = keepWebHookSecrets(t.WebHooks, stored.WebHooks)
+
+	if len(t.EventWebHooks) > 0 {
+		eventWebHooks := make(map[string][]data.WebHook)
+		for event, hooks := range t.EventWebHooks {
+			eventWebHooks[event] = keepWebHookSecrets(hooks, stored.EventWebHooks[event])
+		}
+		t.EventWebHooks = eventWebHooks
+	}
+
+	return t
+}
+
+// keepWebHookSecrets keeps the saved secret for each webhook with secretset (but no secret)
+func keepWebHookSecrets(hooks, stored []data.WebHook) []data.WebHook {
+	if hooks == nil {
+		return nil
+	}
+
+	retval := make([]data.WebHook, 0, len(hooks))
+	for i, hook := range hooks {
+		if hook.Secret == "" && hook.SecretSet && i < len(stored) {
+			hook.Secret = stored[i].Secret
+		}
+
+		//	Whether there's a secret is worked out when the trigger is returned
+		hook.SecretSet = false
+		retval = append(retval, hook)
+	}
+	return retval
+}
+
+// checkPin makes sure the trigger can use its GPIO pin alongside the other enabled triggers
+func (service Service) checkPin(t data.Trigger) error {
+	allTriggers, err := service.DB.GetAllTriggers()
+	if err != nil {
+		log.Err(err).Msg("Problem getting triggers to check pins.  Only checking running triggers")
+	}
+
+	return service.Pins.Check(t, allTriggers)
+}
+
+// reconcile brings the monitors for a trigger in line with the stored trigger, and waits until they are
+func (service Service) reconcile(triggerID string) {
+	done := make(chan struct{})
+	service.Reconcile <- trigger.ReconcileRequest{TriggerID: triggerID, Done: done}
+	<-done
+}
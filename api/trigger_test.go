@@ -0,0 +1,672 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danesparza/fxtrigger/api"
+	"github.com/danesparza/fxtrigger/internal/data"
+)
+
+func TestTrigger_CreateTrigger_InvalidRetryOn_BadRequest(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+
+	//	Act
+	rr := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:     "Retry trigger",
+		GPIOPin:  17,
+		WebHooks: []data.WebHook{{URL: h.hookTS.URL, MaxRetries: 3, RetryOn: []string{"sometimes"}}},
+	})
+
+	//	Assert
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("CreateTrigger failed: Should have returned a bad request but got: %v", rr.Code)
+	}
+}
+
+func TestTrigger_CreateTrigger_InvalidMethod_BadRequest(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+
+	//	Act
+	rr := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:     "Method trigger",
+		GPIOPin:  17,
+		WebHooks: []data.WebHook{{URL: h.hookTS.URL, Method: "CONNECT"}},
+	})
+
+	//	Assert
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("CreateTrigger failed: Should have returned a bad request but got: %v", rr.Code)
+	}
+}
+
+func TestTrigger_CreateTrigger_InvalidEdge_BadRequest(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+
+	//	Act
+	rr := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:     "Edge trigger",
+		GPIOPin:  17,
+		Edge:     "sideways",
+		WebHooks: []data.WebHook{{URL: h.hookTS.URL}},
+	})
+
+	//	Assert
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("CreateTrigger failed: Should have returned a bad request but got: %v", rr.Code)
+	}
+}
+
+func TestTrigger_CreateTrigger_InvalidPull_BadRequest(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+
+	//	Act
+	rr := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:     "Pull trigger",
+		GPIOPin:  17,
+		Pull:     "sideways",
+		WebHooks: []data.WebHook{{URL: h.hookTS.URL}},
+	})
+
+	//	Assert
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("CreateTrigger failed: Should have returned a bad request but got: %v", rr.Code)
+	}
+}
+
+func TestTrigger_CreateTrigger_InvalidSchedule_BadRequest(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+
+	//	Act
+	rr := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:     "Time trigger",
+		Type:     "Time",
+		Schedule: "every other tuesday",
+		WebHooks: []data.WebHook{{URL: h.hookTS.URL}},
+	})
+
+	//	Assert
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("CreateTrigger failed: Should have returned a bad request but got: %v", rr.Code)
+	}
+}
+
+func TestTrigger_CreateTrigger_TimeTrigger_FiresOnSchedule(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+
+	//	Act
+	rr := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:     "Time trigger",
+		Type:     "Time",
+		Schedule: "@every 1s",
+		WebHooks: []data.WebHook{{URL: h.hookTS.URL}},
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("CreateTrigger failed: %v %s", rr.Code, rr.Body.String())
+	}
+
+	//	Assert
+	select {
+	case <-h.hooks:
+	case <-time.After(3 * time.Second):
+		t.Errorf("CreateTrigger failed: Timed out waiting for the scheduled webhook")
+	}
+}
+
+func TestTrigger_ListAllTriggers_TimeTrigger_IncludesNextFireTime(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+	rr := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:     "Time trigger",
+		Type:     "Time",
+		Schedule: "0 0 1 1 *",
+		Timezone: "UTC",
+		WebHooks: []data.WebHook{{URL: h.hookTS.URL}},
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("CreateTrigger failed: %v %s", rr.Code, rr.Body.String())
+	}
+
+	//	Act
+	rr = h.call(http.MethodGet, "/v1/triggers", nil)
+
+	//	Assert
+	response := struct {
+		Data []api.TriggerResponse `json:"data"`
+	}{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("ListAllTriggers failed: %s", err)
+	}
+
+	if len(response.Data) != 1 || response.Data[0].NextFireTime == nil {
+		t.Fatalf("ListAllTriggers failed: Should have included the next fire time: %s", rr.Body.String())
+	}
+
+	next := response.Data[0].NextFireTime.UTC()
+	if next.Month() != time.January || next.Day() != 1 || next.Hour() != 0 || !next.After(time.Now()) {
+		t.Errorf("ListAllTriggers failed: Unexpected next fire time: %v", next)
+	}
+}
+
+func TestTrigger_CreateTrigger_InvalidSystemEvent_BadRequest(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+
+	//	Act
+	rr := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:        "System trigger",
+		Type:        "System",
+		SystemEvent: "lunchtime",
+		WebHooks:    []data.WebHook{{URL: h.hookTS.URL}},
+	})
+
+	//	Assert
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("CreateTrigger failed: Should have returned a bad request but got: %v", rr.Code)
+	}
+}
+
+func TestTrigger_CreateTrigger_InvalidFields_ListsEachField(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+
+	//	Act
+	rr := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:     "Bad trigger",
+		GPIOPin:  40,
+		Pull:     "sideways",
+		WebHooks: []data.WebHook{{URL: ""}},
+	})
+
+	//	Assert
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("CreateTrigger failed: Should have returned a bad request but got: %v", rr.Code)
+	}
+
+	response := api.ErrorResponse{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("CreateTrigger failed: %s", err)
+	}
+
+	fields := []string{}
+	for _, field := range response.Errors {
+		fields = append(fields, field.Field)
+	}
+
+	expected := []string{"gpiopin", "pull", "webhooks[0].url"}
+	if len(fields) != len(expected) {
+		t.Fatalf("CreateTrigger failed: Expected errors for %v but got: %v", expected, fields)
+	}
+
+	for i := range expected {
+		if fields[i] != expected[i] {
+			t.Errorf("CreateTrigger failed: Expected errors for %v but got: %v", expected, fields)
+			break
+		}
+	}
+}
+
+func TestTrigger_CreateTrigger_TimeWithoutSchedule_BadRequest(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+
+	//	Act
+	rr := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:     "Time trigger",
+		Type:     "Time",
+		WebHooks: []data.WebHook{{URL: h.hookTS.URL}},
+	})
+
+	//	Assert
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("CreateTrigger failed: Should have returned a bad request but got: %v", rr.Code)
+	}
+}
+
+func TestTrigger_CreateTrigger_NoType_DefaultsToMotion(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+
+	//	Act
+	rr := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:     "Motion sensor",
+		GPIOPin:  17,
+		WebHooks: []data.WebHook{{URL: h.hookTS.URL}},
+	})
+
+	//	Assert
+	response := struct {
+		Data api.TriggerResponse `json:"data"`
+	}{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("CreateTrigger failed: %s", err)
+	}
+
+	if response.Data.Type != "Motion" {
+		t.Errorf("CreateTrigger failed: Should have defaulted the type to Motion but got: %v", response.Data.Type)
+	}
+}
+
+func TestTrigger_CreateTrigger_ConflictingPin_Conflict(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+	rr := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:     "Pulled up",
+		GPIOPin:  17,
+		Pull:     "up",
+		WebHooks: []data.WebHook{{URL: h.hookTS.URL}},
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("CreateTrigger failed: %v %s", rr.Code, rr.Body.String())
+	}
+
+	//	Act
+	conflicting := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:     "Pulled down",
+		GPIOPin:  17,
+		Pull:     "down",
+		WebHooks: []data.WebHook{{URL: h.hookTS.URL}},
+	})
+	reserved := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:     "On the UART",
+		GPIOPin:  14,
+		WebHooks: []data.WebHook{{URL: h.hookTS.URL}},
+	})
+	sharing := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:     "Also pulled up",
+		Type:     "Button",
+		GPIOPin:  17,
+		Pull:     "up",
+		WebHooks: []data.WebHook{{URL: h.hookTS.URL}},
+	})
+
+	//	Assert
+	if conflicting.Code != http.StatusConflict {
+		t.Errorf("CreateTrigger failed: Should have returned a conflict for a different pull but got: %v", conflicting.Code)
+	}
+
+	if reserved.Code != http.StatusConflict {
+		t.Errorf("CreateTrigger failed: Should have returned a conflict for a reserved pin but got: %v", reserved.Code)
+	}
+
+	if sharing.Code != http.StatusOK {
+		t.Errorf("CreateTrigger failed: Compatible triggers should share a pin but got: %v %s", sharing.Code, sharing.Body.String())
+	}
+}
+
+func TestTrigger_GetTrigger_DoesNotExist_NotFound(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+
+	//	Act
+	rr := h.call(http.MethodGet, "/v1/triggers/unknown", nil)
+
+	//	Assert
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("GetTrigger failed: Should have returned not found but got: %v", rr.Code)
+	}
+}
+
+func TestTrigger_GetTrigger_WebHookSecret_NotReturned(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+	rr := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:          "Motion sensor",
+		GPIOPin:       17,
+		WebHooks:      []data.WebHook{{URL: h.hookTS.URL, Secret: "very-secret"}},
+		EventWebHooks: map[string][]data.WebHook{"deactivated": {{URL: h.hookTS.URL, Secret: "very-secret"}}},
+	})
+	created := struct {
+		Data api.TriggerResponse `json:"data"`
+	}{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil {
+		t.Fatalf("CreateTrigger failed: %s", err)
+	}
+
+	//	Act
+	get := h.call(http.MethodGet, "/v1/triggers/"+created.Data.ID, nil)
+	list := h.call(http.MethodGet, "/v1/triggers", nil)
+	fire := h.call(http.MethodPost, "/v1/trigger/fire/"+created.Data.ID, nil)
+
+	//	Assert
+	for name, resp := range map[string]*httptest.ResponseRecorder{"CreateTrigger": rr, "GetTrigger": get, "ListAllTriggers": list, "FireSingleTrigger": fire} {
+		if strings.Contains(resp.Body.String(), "very-secret") {
+			t.Errorf("%v failed: Shouldn't have returned the webhook secret but got: %v", name, resp.Body.String())
+		}
+	}
+
+	got := struct {
+		Data api.TriggerResponse `json:"data"`
+	}{}
+	if err := json.Unmarshal(get.Body.Bytes(), &got); err != nil {
+		t.Fatalf("GetTrigger failed: %s", err)
+	}
+
+	if len(got.Data.WebHooks) != 1 || !got.Data.WebHooks[0].SecretSet || !got.Data.EventWebHooks["deactivated"][0].SecretSet {
+		t.Errorf("GetTrigger failed: Should have reported the webhook secrets are set but got: %+v", got.Data)
+	}
+
+	stored, _ := h.service.DB.GetTrigger(created.Data.ID)
+	if stored.WebHooks[0].Secret != "very-secret" {
+		t.Errorf("CreateTrigger failed: Should have saved the webhook secret but got: %+v", stored.WebHooks[0])
+	}
+}
+
+func TestTrigger_PatchAndUpdateTrigger_ReturnedTrigger_KeepsSecrets(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+	rr := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:          "Motion sensor",
+		GPIOPin:       17,
+		WebHooks:      []data.WebHook{{URL: h.hookTS.URL + "/first", Secret: "first-secret"}, {URL: h.hookTS.URL + "/second"}},
+		EventWebHooks: map[string][]data.WebHook{"deactivated": {{URL: h.hookTS.URL, Secret: "event-secret"}}},
+	})
+	created := struct {
+		Data api.TriggerResponse `json:"data"`
+	}{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil {
+		t.Fatalf("CreateTrigger failed: %s", err)
+	}
+
+	get := struct {
+		Data map[string]any `json:"data"`
+	}{}
+	if err := json.Unmarshal(h.call(http.MethodGet, "/v1/triggers/"+created.Data.ID, nil).Body.Bytes(), &get); err != nil {
+		t.Fatalf("GetTrigger failed: %s", err)
+	}
+	returned := get.Data
+	returned["description"] = "Changed"
+	returned["nextfiretime"] = time.Now()
+
+	for _, method := range []string{http.MethodPatch, http.MethodPut} {
+		//	Act
+		rr = h.call(method, "/v1/triggers/"+created.Data.ID, returned)
+		stored, _ := h.service.DB.GetTrigger(created.Data.ID)
+
+		//	Assert
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%v failed: Should accept the returned trigger but got: %v %s", method, rr.Code, rr.Body.String())
+		}
+
+		if stored.Description != "Changed" {
+			t.Errorf("%v failed: Should have changed the trigger but got: %+v", method, stored)
+		}
+
+		if stored.WebHooks[0].Secret != "first-secret" || stored.WebHooks[1].Secret != "" || stored.EventWebHooks["deactivated"][0].Secret != "event-secret" {
+			t.Errorf("%v failed: Should have kept the saved secrets but got: %+v %+v", method, stored.WebHooks, stored.EventWebHooks)
+		}
+	}
+}
+
+func TestTrigger_PatchTrigger_NewSecretAndRemovedSecret_Changed(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+	rr := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:     "Motion sensor",
+		GPIOPin:  17,
+		WebHooks: []data.WebHook{{URL: h.hookTS.URL + "/first", Secret: "first-secret"}, {URL: h.hookTS.URL + "/second", Secret: "second-secret"}},
+	})
+	created := struct {
+		Data api.TriggerResponse `json:"data"`
+	}{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil {
+		t.Fatalf("CreateTrigger failed: %s", err)
+	}
+
+	//	Act
+	rr = h.call(http.MethodPatch, "/v1/triggers/"+created.Data.ID, map[string]any{
+		"webhooks": []map[string]any{
+			{"url": h.hookTS.URL + "/first", "secret": "new-secret", "secretset": true},
+			{"url": h.hookTS.URL + "/second"},
+		},
+	})
+	stored, _ := h.service.DB.GetTrigger(created.Data.ID)
+
+	//	Assert
+	if rr.Code != http.StatusOK {
+		t.Fatalf("PatchTrigger failed: %v %s", rr.Code, rr.Body.String())
+	}
+
+	if stored.WebHooks[0].Secret != "new-secret" || stored.WebHooks[1].Secret != "" {
+		t.Errorf("PatchTrigger failed: Should have changed the first secret and removed the second but got: %+v", stored.WebHooks)
+	}
+}
+
+func TestTrigger_PatchTrigger_ClearsAndSetsZeroValues_Successful(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+	rr := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:                          "Motion sensor",
+		Description:                   "Hallway",
+		GPIOPin:                       17,
+		MinimumSecondsBeforeRetrigger: 30,
+		WebHooks:                      []data.WebHook{{URL: h.hookTS.URL}},
+	})
+	created := struct {
+		Data api.TriggerResponse `json:"data"`
+	}{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil {
+		t.Fatalf("CreateTrigger failed: %s", err)
+	}
+
+	//	Act
+	rr = h.call(http.MethodPatch, "/v1/triggers/"+created.Data.ID, map[string]any{
+		"description": nil,
+		"enabled":     false,
+		"gpiopin":     0,
+		"id":          "something-else",
+	})
+	get := h.call(http.MethodGet, "/v1/triggers/"+created.Data.ID, nil)
+
+	//	Assert
+	if rr.Code != http.StatusOK {
+		t.Fatalf("PatchTrigger failed: %v %s", rr.Code, rr.Body.String())
+	}
+
+	response := struct {
+		Data api.TriggerResponse `json:"data"`
+	}{}
+	if err := json.Unmarshal(get.Body.Bytes(), &response); err != nil {
+		t.Fatalf("GetTrigger failed: %s", err)
+	}
+
+	if response.Data.ID != created.Data.ID {
+		t.Errorf("PatchTrigger failed: Should not have changed the id but got: %v", response.Data.ID)
+	}
+
+	if response.Data.Description != "" {
+		t.Errorf("PatchTrigger failed: Should have cleared the description but got: %v", response.Data.Description)
+	}
+
+	if response.Data.GPIOPin != 0 {
+		t.Errorf("PatchTrigger failed: Should have set the pin to 0 but got: %v", response.Data.GPIOPin)
+	}
+
+	if response.Data.Enabled {
+		t.Errorf("PatchTrigger failed: Should have disabled the trigger")
+	}
+
+	if response.Data.Name != "Motion sensor" || response.Data.MinimumSecondsBeforeRetrigger != 30 {
+		t.Errorf("PatchTrigger failed: Should have kept the other settings but got: %+v", response.Data.Trigger)
+	}
+}
+
+func TestTrigger_PatchTrigger_UnknownField_BadRequest(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+	rr := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:     "Motion sensor",
+		GPIOPin:  17,
+		WebHooks: []data.WebHook{{URL: h.hookTS.URL}},
+	})
+	created := struct {
+		Data api.TriggerResponse `json:"data"`
+	}{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil {
+		t.Fatalf("CreateTrigger failed: %s", err)
+	}
+
+	//	Act
+	rr = h.call(http.MethodPatch, "/v1/triggers/"+created.Data.ID, map[string]any{"gpio_pin": 4})
+
+	//	Assert
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("PatchTrigger failed: Should have returned a bad request but got: %v", rr.Code)
+	}
+}
+
+func TestTrigger_UpdateTrigger_ReplacesAllSettings(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+	rr := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:                          "Motion sensor",
+		Description:                   "Hallway",
+		GPIOPin:                       17,
+		MinimumSecondsBeforeRetrigger: 30,
+		WebHooks:                      []data.WebHook{{URL: h.hookTS.URL}},
+	})
+	created := struct {
+		Data api.TriggerResponse `json:"data"`
+	}{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil {
+		t.Fatalf("CreateTrigger failed: %s", err)
+	}
+
+	//	Act
+	rr = h.call(http.MethodPut, "/v1/triggers/"+created.Data.ID, api.UpdateTriggerRequest{
+		Name:     "Doorbell",
+		GPIOPin:  0,
+		WebHooks: []data.WebHook{{URL: h.hookTS.URL}},
+	})
+
+	//	Assert
+	if rr.Code != http.StatusOK {
+		t.Fatalf("UpdateTrigger failed: %v %s", rr.Code, rr.Body.String())
+	}
+
+	response := struct {
+		Data api.TriggerResponse `json:"data"`
+	}{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("UpdateTrigger failed: %s", err)
+	}
+
+	if response.Data.Name != "Doorbell" || response.Data.Description != "" || response.Data.GPIOPin != 0 || response.Data.MinimumSecondsBeforeRetrigger != 0 || response.Data.Enabled {
+		t.Errorf("UpdateTrigger failed: Should have replaced every setting but got: %+v", response.Data.Trigger)
+	}
+
+	if !response.Data.Created.Equal(created.Data.Created) {
+		t.Errorf("UpdateTrigger failed: Should have kept the create time but got: %v", response.Data.Created)
+	}
+}
+
+func TestTrigger_DisableTrigger_StopsFiring_EnableTrigger_FiresAgain(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+	rr := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:     "Motion sensor",
+		GPIOPin:  17,
+		WebHooks: []data.WebHook{{URL: h.hookTS.URL}},
+	})
+	created := struct {
+		Data api.TriggerResponse `json:"data"`
+	}{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil {
+		t.Fatalf("CreateTrigger failed: %s", err)
+	}
+
+	//	Act
+	disabled := h.call(http.MethodPost, "/v1/triggers/"+created.Data.ID+"/disable", nil)
+	h.call(http.MethodPost, "/v1/sim/pins/17", api.SetSimPinRequest{Action: "pulse", PulseMillis: 500})
+	firedWhileDisabled := h.hookCount(1 * time.Second)
+
+	enabled := h.call(http.MethodPost, "/v1/triggers/"+created.Data.ID+"/enable", nil)
+	h.call(http.MethodPost, "/v1/sim/pins/17", api.SetSimPinRequest{Action: "pulse", PulseMillis: 500})
+	firedWhileEnabled := h.hookCount(2 * time.Second)
+
+	//	Assert
+	if disabled.Code != http.StatusOK || enabled.Code != http.StatusOK {
+		t.Fatalf("DisableTrigger / EnableTrigger failed: %v %v", disabled.Code, enabled.Code)
+	}
+
+	if firedWhileDisabled != 0 {
+		t.Errorf("DisableTrigger failed: Should not have fired but got: %v", firedWhileDisabled)
+	}
+
+	if firedWhileEnabled != 1 {
+		t.Errorf("EnableTrigger failed: Should have fired once but got: %v", firedWhileEnabled)
+	}
+}
+
+func TestTrigger_PatchTrigger_RepeatedEdits_FiresOnce(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+	rr := h.call(http.MethodPost, "/v1/triggers", api.CreateTriggerRequest{
+		Name:     "Motion sensor",
+		GPIOPin:  17,
+		WebHooks: []data.WebHook{{URL: h.hookTS.URL}},
+	})
+	created := struct {
+		Data api.TriggerResponse `json:"data"`
+	}{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil {
+		t.Fatalf("CreateTrigger failed: %s", err)
+	}
+
+	//	Act
+	for i := 0; i < 5; i++ {
+		h.call(http.MethodPatch, "/v1/triggers/"+created.Data.ID, map[string]any{"description": fmt.Sprintf("Edit %v", i)})
+	}
+	h.call(http.MethodPost, "/v1/sim/pins/17", api.SetSimPinRequest{Action: "pulse", PulseMillis: 500})
+
+	//	Assert
+	if count := h.hookCount(2 * time.Second); count != 1 {
+		t.Errorf("PatchTrigger failed: Should have fired the webhook once but got: %v", count)
+	}
+}
+
+func TestTrigger_EnableTrigger_DoesNotExist_NotFound(t *testing.T) {
+
+	//	Arrange
+	h := newSimHarness(t)
+
+	//	Act
+	rr := h.call(http.MethodPost, "/v1/triggers/unknown/enable", nil)
+
+	//	Assert
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("EnableTrigger failed: Should have returned not found but got: %v", rr.Code)
+	}
+}
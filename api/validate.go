@@ -0,0 +1,198 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/danesparza/fxtrigger/internal/data"
+	"github.com/danesparza/fxtrigger/internal/gpio"
+	"github.com/danesparza/fxtrigger/internal/trigger"
+	"github.com/danesparza/fxtrigger/internal/triggerevent"
+	"github.com/danesparza/fxtrigger/internal/triggertype"
+)
+
+// FieldError is a problem with a single field in a request
+type FieldError struct {
+	Field   string `json:"field"`   // The field (like 'gpiopin' or 'webhooks[0].url')
+	Message string `json:"message"` // What's wrong with it
+}
+
+// ValidationError is a request with one or more invalid fields
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Error lists each invalid field
+func (e ValidationError) Error() string {
+	problems := []string{}
+	for _, field := range e.Fields {
+		problems = append(problems, field.Field+" "+field.Message)
+	}
+	return "invalid request: " + strings.Join(problems, "; ")
+}
+
+// fieldErrors collects the invalid fields in a request
+type fieldErrors []FieldError
+
+// add records an invalid field
+func (f *fieldErrors) add(field, format string, args ...any) {
+	*f = append(*f, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// err returns a ValidationError if any fields are invalid (or nil if they're all valid)
+func (f fieldErrors) err() error {
+	if len(f) == 0 {
+		return nil
+	}
+	return ValidationError{Fields: f}
+}
+
+// triggerTypes are the valid trigger types
+var triggerTypes = []string{triggertype.Motion, triggertype.Button, triggertype.Time, triggertype.System}
+
+// eventsWithWebHooks are the trigger events (for each type of trigger) that can have their own webhooks
+var eventsWithWebHooks = map[string][]string{
+	triggertype.Motion: {triggerevent.Activated, triggerevent.Deactivated},
+	triggertype.Button: {triggerevent.Press, triggerevent.DoublePress, triggerevent.LongPress, triggerevent.Hold},
+}
+
+// validateTrigger makes sure the settings for a trigger are valid for its type.  Settings
+// that don't apply to the type are ignored.  If anything is invalid, a ValidationError is returned
+func validateTrigger(t data.Trigger) error {
+	problems := fieldErrors{}
+
+	switch t.Type {
+	case triggertype.Motion, triggertype.Button:
+		validatePinSettings(t, &problems)
+	case triggertype.Time:
+		if strings.TrimSpace(t.Schedule) == "" {
+			problems.add("schedule", "is required for %v triggers", t.Type)
+		} else if _, _, err := trigger.ParseSchedule(t.Schedule, ""); err != nil {
+			problems.add("schedule", "must be a cron expression (like '30 7 * * 1-5' or '@hourly'): %v", err)
+		}
+
+		if t.Timezone != "" {
+			if _, err := time.LoadLocation(t.Timezone); err != nil {
+				problems.add("timezone", "must be a timezone name (like 'America/New_York')")
+			}
+		}
+	case triggertype.System:
+		if !slices.Contains(trigger.SystemEvents, t.SystemEvent) {
+			problems.add("systemevent", "must be one of: %v", strings.Join(trigger.SystemEvents, ", "))
+		}
+	default:
+		problems.add("type", "must be one of: %v", strings.Join(triggerTypes, ", "))
+	}
+
+	if len(t.WebHooks) < 1 {
+		problems.add("webhooks", "must include at least one webhook")
+	}
+	validateWebHooks("webhooks", t.WebHooks, &problems)
+
+	for event, hooks := range t.EventWebHooks {
+		events := eventsWithWebHooks[t.Type]
+		if !slices.Contains(events, event) {
+			if len(events) == 0 {
+				problems.add("eventwebhooks", "aren't used by %v triggers", t.Type)
+			} else {
+				problems.add("eventwebhooks", "events for %v triggers must be one of: %v", t.Type, strings.Join(events, ", "))
+			}
+			continue
+		}
+
+		validateWebHooks(fmt.Sprintf("eventwebhooks[%v]", event), hooks, &problems)
+	}
+
+	return problems.err()
+}
+
+// validatePinSettings makes sure the pin settings for a (GPIO) trigger are valid
+func validatePinSettings(t data.Trigger, problems *fieldErrors) {
+	if t.GPIOPin < 0 || t.GPIOPin > gpio.MaxPin {
+		problems.add("gpiopin", "must be between 0 and %v", gpio.MaxPin)
+	}
+
+	if t.Pull != "" {
+		if _, err := gpio.ParsePull(t.Pull); err != nil {
+			problems.add("pull", "must be one of: %v, %v, %v", gpio.PullUpName, gpio.PullDownName, gpio.PullOffName)
+		}
+	}
+
+	if t.SampleIntervalMillis < 0 {
+		problems.add("sampleintervalms", "can't be negative")
+	}
+
+	if t.DebounceMillis < 0 {
+		problems.add("debouncems", "can't be negative")
+	}
+
+	if t.Type == triggertype.Motion {
+		if _, err := gpio.ParseEdge(t.Edge); err != nil {
+			problems.add("edge", "must be one of: %v, %v, %v", gpio.RisingEdgeName, gpio.FallingEdgeName, gpio.BothEdgeName)
+		}
+	}
+
+	if t.Type == triggertype.Button {
+		if t.LongPressMillis < 0 {
+			problems.add("longpressms", "can't be negative")
+		}
+
+		if t.DoublePressMillis < 0 {
+			problems.add("doublepressms", "can't be negative")
+		}
+
+		if t.HoldRepeatMillis < 0 {
+			problems.add("holdrepeatms", "can't be negative")
+		}
+	}
+}
+
+// validateWebHooks makes sure the settings for each webhook are valid
+func validateWebHooks(field string, hooks []data.WebHook, problems *fieldErrors) {
+	for i, hook := range hooks {
+		prefix := fmt.Sprintf("%v[%v]", field, i)
+
+		if strings.TrimSpace(hook.URL) == "" {
+			problems.add(prefix+".url", "is required")
+		}
+
+		switch trigger.WebHookMethod(hook) {
+		case http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodPost:
+		default:
+			problems.add(prefix+".method", "must be one of: GET, PUT, PATCH, DELETE, POST")
+		}
+
+		if err := trigger.ValidateWebHookTemplates(hook); err != nil {
+			problems.add(prefix, "has an invalid template: %v", err)
+		}
+
+		if hook.MaxRetries < 0 {
+			problems.add(prefix+".maxretries", "can't be negative")
+		}
+
+		if hook.InitialBackoff < 0 {
+			problems.add(prefix+".initialbackoff", "can't be negative")
+		}
+
+		if hook.MaxBackoff < 0 {
+			problems.add(prefix+".maxbackoff", "can't be negative")
+		} else if hook.MaxBackoff > 0 && hook.MaxBackoff < hook.InitialBackoff {
+			problems.add(prefix+".maxbackoff", "can't be less than initialbackoff")
+		}
+
+		if hook.Timeout < 0 {
+			problems.add(prefix+".timeout", "can't be negative")
+		}
+
+		for _, condition := range hook.RetryOn {
+			switch condition {
+			case trigger.RetryOnNetwork, trigger.RetryOn4xx, trigger.RetryOn5xx:
+			default:
+				problems.add(prefix+".retryon", "must be one of: %v, %v, %v", trigger.RetryOnNetwork, trigger.RetryOn4xx, trigger.RetryOn5xx)
+			}
+		}
+	}
+}
@@ -69,11 +69,23 @@ func initConfig() {
 	//	Set our defaults
 	viper.SetDefault("datastore.system", path.Join(home, "fxtrigger", "db", "system.db"))
 	viper.SetDefault("datastore.retentiondays", 30)
-	viper.SetDefault("trigger.dndschedule", false) //	Use a 'Do not disturb' schedule
-	viper.SetDefault("trigger.dndstart", "8:00pm") //	Do not disturb scheduled start time
-	viper.SetDefault("trigger.dndend", "6:00am")   //	Do not disturb scheduled end time
+	viper.SetDefault("trigger.dndschedule", false)          //	Use a 'Do not disturb' schedule
+	viper.SetDefault("trigger.dndstart", "8:00pm")          //	Do not disturb scheduled start time
+	viper.SetDefault("trigger.dndend", "6:00am")            //	Do not disturb scheduled end time
+	viper.SetDefault("trigger.dndmode", "suppress")         //	Do not disturb mode: suppress (drop fires) or queue (send them when it ends)
+	viper.SetDefault("trigger.queuesize", 100)              //	The number of fires that can wait to be delivered
+	viper.SetDefault("trigger.workers", 4)                  //	The number of fires that can be delivered at the same time
+	viper.SetDefault("trigger.queueoverflow", "dropoldest") //	When the queue is full: dropoldest, dropnewest or block (wait for room)
+	viper.SetDefault("trigger.queueblocktimeout", "1s")     //	How long to wait for room when queueoverflow is block
+	viper.SetDefault("trigger.webhooksecret", "")           //	Signs webhooks that don't have their own secret.  If empty, they aren't signed
+	viper.SetDefault("gpio.driver", "rpio")                 //	GPIO driver: rpio (Raspberry Pi) or sim (simulated, for development)
+	viper.SetDefault("gpio.allowreservedpins", false)       //	Allow triggers on the pins used by I2C, SPI, UART and the HAT EEPROM
 	viper.SetDefault("server.port", 3020)
 	viper.SetDefault("server.allowed-origins", "*")
+	viper.SetDefault("server.shutdowngraceperiod", "30s") //	How long to wait for webhooks to finish delivering when stopping
+	viper.SetDefault("server.tls.cert", "")               //	The TLS certificate (PEM) file.  If set (with the key), the REST API uses TLS
+	viper.SetDefault("server.tls.key", "")                //	The TLS private key (PEM) file
+	viper.SetDefault("server.tls.clientca", "")           //	If set, clients must present a certificate signed by a CA in this (PEM) bundle
 
 	// If a config file is found, read it in.
 	if err := viper.ReadInConfig(); err != nil {
@@ -3,12 +3,16 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"github.com/danesparza/fxtrigger/internal/certs"
 	"github.com/danesparza/fxtrigger/internal/data"
+	"github.com/danesparza/fxtrigger/internal/gpio"
 	"github.com/danesparza/fxtrigger/internal/trigger"
+	"github.com/danesparza/fxtrigger/internal/triggerevent"
 	"github.com/rs/zerolog/log"
 	"net/http"
 	"os"
 	"os/signal"
+	"reflect"
 	"strings"
 	"syscall"
 	"time"
@@ -40,18 +44,66 @@ func start(cmd *cobra.Command, args []string) {
 	}
 
 	systemdb := viper.GetString("datastore.system")
-	dndschedule := viper.GetString("trigger.dndschedule")
+	retentiondays := viper.GetInt("datastore.retentiondays")
+	gpiodriver := viper.GetString("gpio.driver")
+	allowreservedpins := viper.GetBool("gpio.allowreservedpins")
+	dndschedule := viper.GetBool("trigger.dndschedule")
 	dndstarttime := viper.GetString("trigger.dndstart")
 	dndendtime := viper.GetString("trigger.dndend")
+	dndmode := viper.GetString("trigger.dndmode")
+	queuesize := viper.GetInt("trigger.queuesize")
+	workers := viper.GetInt("trigger.workers")
+	queueoverflow := viper.GetString("trigger.queueoverflow")
+	queueblocktimeout := viper.GetDuration("trigger.queueblocktimeout")
+	webhooksecret := viper.GetString("trigger.webhooksecret")
+	gracePeriod := viper.GetDuration("server.shutdowngraceperiod")
+	tlscert := viper.GetString("server.tls.cert")
+	tlskey := viper.GetString("server.tls.key")
+	tlsclientca := viper.GetString("server.tls.clientca")
 
 	//	Emit what we know:
 	log.Info().
 		Str("systemdb", systemdb).
-		Str("dndschedule", dndschedule).
+		Int("retentiondays", retentiondays).
+		Str("gpiodriver", gpiodriver).
+		Bool("allowreservedpins", allowreservedpins).
+		Bool("dndschedule", dndschedule).
 		Str("dndstarttime", dndstarttime).
 		Str("dndendtime", dndendtime).
+		Str("dndmode", dndmode).
+		Int("queuesize", queuesize).
+		Int("workers", workers).
+		Str("queueoverflow", queueoverflow).
+		Dur("queueblocktimeout", queueblocktimeout).
+		Bool("webhooksigning", webhooksecret != "").
+		Dur("shutdowngraceperiod", gracePeriod).
+		Str("tlscert", tlscert).
+		Str("tlskey", tlskey).
+		Str("tlsclientca", tlsclientca).
 		Msg("Config")
 
+	//	Make sure we know what to do when the fire queue is full
+	if err := trigger.ValidateOverflow(queueoverflow); err != nil {
+		log.Err(err).Msg("Invalid trigger.queueoverflow config")
+		return
+	}
+
+	//	If we have a certificate, use TLS (and mutual TLS if we have a client CA bundle)
+	var tlsCerts *certs.Reloader
+	if tlscert != "" || tlskey != "" || tlsclientca != "" {
+		if tlscert == "" || tlskey == "" {
+			log.Error().Msg("TLS needs both server.tls.cert and server.tls.key")
+			return
+		}
+
+		reloader, err := certs.NewReloader(tlscert, tlskey, tlsclientca)
+		if err != nil {
+			log.Err(err).Msg("Problem loading the TLS certificates")
+			return
+		}
+		tlsCerts = reloader
+	}
+
 	//	Create a DBManager object and associate with the api.Service
 	db, err := data.NewManager(systemdb)
 	if err != nil {
@@ -60,28 +112,88 @@ func start(cmd *cobra.Command, args []string) {
 	}
 	defer db.Close()
 
+	//	The first time we start, use the config for the do not disturb settings.
+	//	After that, they're managed with the REST API
+	configDND := data.DNDSettings{
+		Enabled: dndschedule,
+		Mode:    dndmode,
+		Windows: []data.DNDWindow{{Start: dndstarttime, End: dndendtime}},
+	}
+
+	savedDND, found, err := db.GetDNDSettings()
+	switch {
+	case err != nil:
+		log.Err(err).Msg("Problem getting the do not disturb settings")
+	case !found:
+		if err := trigger.ValidateDNDSettings(configDND); err != nil {
+			log.Err(err).Msg("Invalid do not disturb config.  Not using it")
+		} else if _, err := db.SaveDNDSettings(configDND); err != nil {
+			log.Err(err).Msg("Problem saving the do not disturb settings")
+		}
+	default:
+		//	Overrides are only set with the REST API, so they don't count as a difference
+		savedDND.Override, savedDND.OverrideUntil = "", nil
+		if !reflect.DeepEqual(savedDND, configDND) {
+			log.Warn().Any("saved", savedDND).Msg("The do not disturb config (trigger.dnd*) is only used the first time the service starts.  Using the saved settings instead (change them with the REST API)")
+		}
+	}
+
+	//	Create and open the GPIO driver
+	var gpioDriver gpio.Driver
+	var simDriver *gpio.SimDriver
+	switch strings.ToLower(gpiodriver) {
+	case "rpio":
+		gpioDriver = gpio.NewRPIODriver()
+	case "sim":
+		simDriver = gpio.NewSimDriver()
+		gpioDriver = simDriver
+	default:
+		log.Error().Str("gpiodriver", gpiodriver).Msg("Unknown GPIO driver.  Use 'rpio' or 'sim'")
+		return
+	}
+
+	if err := gpioDriver.Open(); err != nil {
+		log.Err(err).Msg("Problem trying to open GPIO")
+		return
+	}
+	defer gpioDriver.Close()
+
 	//	Create a background service object
 	backgroundService := trigger.BackgroundProcess{
-		FireTrigger:   make(chan data.Trigger),
-		AddMonitor:    make(chan data.Trigger),
-		RemoveMonitor: make(chan string),
-		DB:            db,
+		FireQueue: trigger.NewFireQueue(trigger.FireQueueOptions{
+			Size:         queuesize,
+			Workers:      workers,
+			Overflow:     queueoverflow,
+			BlockTimeout: queueblocktimeout,
+			DB:           db,
+		}),
+		Reconcile:  make(chan trigger.ReconcileRequest),
+		DB:         db,
+		HistoryTTL: time.Duration(retentiondays) * 24 * time.Hour,
+		GPIO:       gpioDriver,
+		Metrics:    trigger.NewDeliveryMetrics(),
+		Pins:       trigger.NewPinRegistry(allowreservedpins),
+
+		WebHookSecret: webhooksecret,
 	}
 
 	//	Create an api service object
 	apiService := api.Service{
-		FireTrigger:   backgroundService.FireTrigger,
-		AddMonitor:    backgroundService.AddMonitor,
-		RemoveMonitor: backgroundService.RemoveMonitor,
-		DB:            db,
-		StartTime:     time.Now(),
+		FireQueue: backgroundService.FireQueue,
+		Reconcile: backgroundService.Reconcile,
+		DB:        db,
+		Metrics:   backgroundService.Metrics,
+		Pins:      backgroundService.Pins,
+		Sim:       simDriver,
+		StartTime: time.Now(),
 	}
 
-	//	Trap program exit appropriately
+	//	Trap program exit appropriately.  Monitors get their own context,
+	//	so they can be stopped before the deliveries they've started
 	ctx, cancel := context.WithCancel(context.Background())
+	monitorctx, stopMonitors := context.WithCancel(ctx)
 	sigs := make(chan os.Signal, 2)
 	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
-	go handleSignals(ctx, sigs, cancel)
 
 	//	Log that the system has started:
 	log.Info().Msg("System started")
@@ -89,56 +201,238 @@ func start(cmd *cobra.Command, args []string) {
 	//	Create a router and setup our REST endpoints...
 	restRouter := mux.NewRouter()
 
+	//	Every request (except the swagger docs) needs an API key with the right scope
+	restRouter.Use(apiService.Authenticate)
+
 	//	TRIGGER ROUTES
-	restRouter.HandleFunc("/v1/triggers", apiService.CreateTrigger).Methods("POST")        // Create a trigger
-	restRouter.HandleFunc("/v1/triggers", apiService.UpdateTrigger).Methods("PUT")         // Update a trigger
-	restRouter.HandleFunc("/v1/triggers", apiService.ListAllTriggers).Methods("GET")       // List all triggers
-	restRouter.HandleFunc("/v1/triggers/{id}", apiService.DeleteTrigger).Methods("DELETE") // Delete a trigger
+	restRouter.HandleFunc("/v1/triggers", apiService.CreateTrigger).Methods("POST")                  // Create a trigger
+	restRouter.HandleFunc("/v1/triggers", apiService.UpdateTrigger).Methods("PUT")                   // Replace a trigger
+	restRouter.HandleFunc("/v1/triggers", apiService.ListAllTriggers).Methods("GET")                 // List all triggers
+	restRouter.HandleFunc("/v1/triggers/{id}", apiService.GetTrigger).Methods("GET")                 // Get a trigger
+	restRouter.HandleFunc("/v1/triggers/{id}", apiService.UpdateTrigger).Methods("PUT")              // Replace a trigger
+	restRouter.HandleFunc("/v1/triggers/{id}", apiService.PatchTrigger).Methods("PATCH")             // Change some settings of a trigger
+	restRouter.HandleFunc("/v1/triggers/{id}", apiService.DeleteTrigger).Methods("DELETE")           // Delete a trigger
+	restRouter.HandleFunc("/v1/triggers/{id}/enable", apiService.EnableTrigger).Methods("POST")      // Enable a trigger
+	restRouter.HandleFunc("/v1/triggers/{id}/disable", apiService.DisableTrigger).Methods("POST")    // Disable a trigger
+	restRouter.HandleFunc("/v1/triggers/{id}/history", apiService.ListTriggerHistory).Methods("GET") // List history for a trigger
 
 	restRouter.HandleFunc("/v1/trigger/fire/{id}", apiService.FireSingleTrigger).Methods("POST") // Fire a trigger
 
+	//	HISTORY ROUTES
+	restRouter.HandleFunc("/v1/history", apiService.ListAllHistory).Methods("GET") // List all history
+
+	//	METRICS ROUTES
+	restRouter.HandleFunc("/v1/metrics/deliveries", apiService.GetDeliveryMetrics).Methods("GET") // Get webhook delivery metrics
+
+	//	STATUS ROUTES
+	restRouter.HandleFunc("/v1/status", apiService.GetStatus).Methods("GET") // Get the service status
+
+	//	DND ROUTES
+	restRouter.HandleFunc("/v1/dnd", apiService.GetDND).Methods("GET")    // Get the do not disturb settings
+	restRouter.HandleFunc("/v1/dnd", apiService.UpdateDND).Methods("PUT") // Update the do not disturb settings
+
+	//	KEY ROUTES
+	restRouter.HandleFunc("/v1/keys", apiService.CreateAPIKey).Methods("POST")        // Create an API key
+	restRouter.HandleFunc("/v1/keys", apiService.ListAllAPIKeys).Methods("GET")       // List all API keys
+	restRouter.HandleFunc("/v1/keys/{id}", apiService.DeleteAPIKey).Methods("DELETE") // Delete an API key
+
+	//	SIM ROUTES (only when using the simulated GPIO driver)
+	if simDriver != nil {
+		restRouter.HandleFunc("/v1/sim/pins/{pin}", apiService.GetSimPin).Methods("GET")  // Get a simulated pin level
+		restRouter.HandleFunc("/v1/sim/pins/{pin}", apiService.SetSimPin).Methods("POST") // Set / pulse a simulated pin
+	}
+
 	//	SWAGGER ROUTES
 	restRouter.PathPrefix("/v1/swagger").Handler(httpSwagger.WrapHandler)
 
 	//	Create background processes to
 	//	- listen for triggers events
 	//	- handle requests to fire a trigger:
-	go backgroundService.ListenForEvents(ctx)
-	go backgroundService.HandleAndProcess(ctx)
+	monitorsStopped := make(chan struct{})
+	go func() {
+		backgroundService.ListenForEvents(monitorctx)
+		close(monitorsStopped)
+	}()
+	processorStopped := make(chan struct{})
+	go func() {
+		backgroundService.HandleAndProcess(ctx)
+		close(processorStopped)
+	}()
+
+	//	Replay any deliveries that didn't finish before the last shutdown
+	backgroundService.ReplayOutbox(ctx)
 
 	//	Initialize monitoring
 	backgroundService.InitializeMonitors()
 
+	//	Let System triggers know we've started, and watch for the network
+	backgroundService.FireSystemEvent(triggerevent.Startup, "")
+	go backgroundService.WatchNetwork(monitorctx)
+
 	//	Setup the CORS options:
 	log.Info().Str("CORS origins", viper.GetString("server.allowed-origins")).Msg("CORS config")
 
 	uiCorsRouter := cors.New(cors.Options{
 		AllowedOrigins:   strings.Split(viper.GetString("server.allowed-origins"), ","),
+		AllowedMethods:   []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete},
+		AllowedHeaders:   []string{"Accept", "Content-Type", "Authorization", api.APIKeyHeader},
 		AllowCredentials: true,
 	}).Handler(restRouter)
 
+	//	Until there's an API key, the API can only be used on the device itself
+	if keys, err := db.GetAllAPIKeys(); err == nil && len(keys) == 0 {
+		log.Warn().Msg("No API keys have been created.  Until one is, the REST API can only be used on this device.  Create one with POST /v1/keys")
+	}
+
 	//	Format the bound interface:
 	formattedServerPort := fmt.Sprintf(":%v", viper.GetString("server.port"))
 
+	//	Shut down in order when we're asked to stop
+	server := &http.Server{Addr: formattedServerPort, Handler: uiCorsRouter}
+	if tlsCerts != nil {
+		server.TLSConfig = tlsCerts.TLSConfig()
+
+		//	Reload the certificates (when they're renewed) on SIGHUP
+		hups := make(chan os.Signal, 1)
+		signal.Notify(hups, syscall.SIGHUP)
+		go reloadOnHangup(hups, tlsCerts)
+	}
+	stopped := make(chan struct{})
+	go handleSignals(sigs, shutdown{
+		server:           server,
+		bp:               backgroundService,
+		stopMonitors:     stopMonitors,
+		monitorsStopped:  monitorsStopped,
+		processorStopped: processorStopped,
+		stopBackground:   cancel,
+		gracePeriod:      gracePeriod,
+	}, stopped)
+
 	//	Start the service and display how to access it
-	log.Info().Str("server", formattedServerPort).Msg("Started REST service")
-	log.Err(http.ListenAndServe(formattedServerPort, uiCorsRouter)).Msg("HTTP API service error")
+	if tlsCerts != nil {
+		log.Info().Str("server", formattedServerPort).Bool("mutualTLS", tlsclientca != "").Msg("Started REST service with TLS")
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		log.Info().Str("server", formattedServerPort).Msg("Started REST service")
+		err = server.ListenAndServe()
+	}
+	if err != http.ErrServerClosed {
+		log.Err(err).Msg("HTTP API service error")
+
+		//	Stop everything, and wait for it to stop before the GPIO driver and the database are closed (deferred above)
+		signal.Stop(sigs)
+		stopMonitors()
+		cancel()
+		waitForStop(monitorsStopped, processorStopped)
+		return
+	}
+
+	//	Wait for the rest of the shutdown.  Then the GPIO driver and the database are closed (deferred above)
+	<-stopped
+	log.Info().Msg("Shutdown complete")
+}
+
+// shutdownEventTimeout is how long to wait for the shutdown system event to be sent
+const shutdownEventTimeout = 10 * time.Second
+
+// processorStopTimeout is the least amount of time to wait for the trigger processor to stop once it's told to
+const processorStopTimeout = 5 * time.Second
+
+// shutdown is everything that needs to be stopped (in order) when the service stops
+type shutdown struct {
+	server           *http.Server              // The REST API
+	bp               trigger.BackgroundProcess // Monitors and deliveries
+	stopMonitors     context.CancelFunc        // Stops the monitors
+	monitorsStopped  <-chan struct{}           // Closed once the monitors have stopped
+	stopBackground   context.CancelFunc        // Stops everything else (including deliveries that haven't finished)
+	processorStopped <-chan struct{}           // Closed once the trigger processor (and its workers) have stopped
+	gracePeriod      time.Duration             // How long to wait for everything to finish
 }
 
-func handleSignals(ctx context.Context, sigs <-chan os.Signal, cancel context.CancelFunc) {
+// handleSignals waits for a signal to stop, shuts everything down and then closes stopped.
+// If the processor stops first (because the REST service couldn't start), it just returns
+func handleSignals(sigs <-chan os.Signal, s shutdown, stopped chan<- struct{}) {
+	var sig os.Signal
+	select {
+	case sig = <-sigs:
+	case <-s.processorStopped:
+		return
+	}
+	switch sig {
+	case os.Interrupt:
+		log.Info().Msg("SIGINT")
+	case syscall.SIGTERM:
+		log.Info().Msg("SIGTERM")
+	}
+
+	log.Info().Dur("gracePeriod", s.gracePeriod).Msg("Shutting down ...")
+	gracectx, gracecancel := context.WithTimeout(context.Background(), s.gracePeriod)
+	defer gracecancel()
+
+	//	Stop accepting API requests (and let the ones in progress finish)
+	if err := s.server.Shutdown(gracectx); err != nil {
+		log.Err(err).Msg("Problem stopping the REST service")
+	}
+
+	//	Let System triggers know we're stopping (and wait for them to be sent)
+	eventctx, eventcancel := context.WithTimeout(gracectx, shutdownEventTimeout)
+	s.bp.DeliverSystemEvent(eventctx, triggerevent.Shutdown, sig.String())
+	eventcancel()
+
+	//	Stop the monitors, so nothing else fires
+	s.stopMonitors()
 	select {
-	case <-ctx.Done():
-	case sig := <-sigs:
-		switch sig {
-		case os.Interrupt:
-			log.Info().Msg("SIGINT")
-		case syscall.SIGTERM:
-			log.Info().Msg("SIGTERM")
+	case <-s.monitorsStopped:
+	case <-gracectx.Done():
+		log.Warn().Msg("Monitors didn't stop in the grace period")
+	}
+
+	//	Let the fires that are already queued finish delivering.  Anything that doesn't
+	//	finish in time is left in the outbox (and replayed at startup)
+	if err := s.bp.FireQueue.Drain(gracectx); err != nil {
+		log.Warn().Msg("Deliveries didn't finish in the grace period.  They will be replayed at startup")
+	}
+
+	//	Stop the processor and wait for its workers, so nothing uses the database after it's closed.
+	//	Deliveries still running are cancelled, so even if the grace period is over they stop quickly
+	s.stopBackground()
+	deadline, _ := gracectx.Deadline()
+	stopctx, stopcancel := context.WithTimeout(context.Background(), max(time.Until(deadline), processorStopTimeout))
+	defer stopcancel()
+
+	select {
+	case <-s.processorStopped:
+	case <-stopctx.Done():
+		log.Warn().Msg("Trigger processor didn't stop in time")
+	}
+
+	close(stopped)
+}
+
+// waitForStop waits (up to processorStopTimeout) for each background process to stop
+func waitForStop(stopped ...<-chan struct{}) {
+	waitctx, waitcancel := context.WithTimeout(context.Background(), processorStopTimeout)
+	defer waitcancel()
+
+	for _, done := range stopped {
+		select {
+		case <-done:
+		case <-waitctx.Done():
+			log.Warn().Msg("Background processes didn't stop in time")
+			return
 		}
+	}
+}
 
-		log.Info().Msg("Shutting down ...")
-		cancel()
-		os.Exit(0)
+// reloadOnHangup reloads the TLS certificates each time the service gets a SIGHUP
+func reloadOnHangup(hups <-chan os.Signal, tlsCerts *certs.Reloader) {
+	for range hups {
+		if err := tlsCerts.Reload(); err != nil {
+			log.Err(err).Msg("Problem reloading the TLS certificates.  Still using the old ones")
+			continue
+		}
+
+		log.Info().Msg("TLS certificates reloaded")
 	}
 }
 
@@ -20,9 +20,817 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/dnd": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the do not disturb settings, and whether do not disturb is active right now",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "dnd"
+                ],
+                "summary": "Get the do not disturb settings",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SystemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Replace the do not disturb settings.  Use override (on or off) to override the windows, optionally until overrideuntil",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "dnd"
+                ],
+                "summary": "Update the do not disturb settings",
+                "parameters": [
+                    {
+                        "description": "The do not disturb settings",
+                        "name": "settings",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/data.DNDSettings"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SystemResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/history": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List trigger firing history (newest first)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "history"
+                ],
+                "summary": "List trigger firing history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only include items at or after this time (RFC3339)",
+                        "name": "start",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include items before this time (RFC3339)",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "The number of items to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "The maximum number of items to return (default 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SystemResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/keys": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List all API keys (without the keys themselves)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "keys"
+                ],
+                "summary": "List all API keys",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SystemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create an API key.  The key is only returned now, so keep it somewhere safe",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "keys"
+                ],
+                "summary": "Create an API key",
+                "parameters": [
+                    {
+                        "description": "The API key to create",
+                        "name": "key",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.CreateAPIKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SystemResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/keys/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete an API key.  The last key with the admin scope can't be deleted while other keys exist",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "keys"
+                ],
+                "summary": "Delete an API key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The API key id to delete",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SystemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/metrics/deliveries": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get webhook delivery statistics (per URL) since the service started",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "metrics"
+                ],
+                "summary": "Get webhook delivery metrics",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SystemResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/sim/pins/{pin}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Gets the level of a simulated GPIO pin.  Only available when using the sim GPIO driver",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sim"
+                ],
+                "summary": "Gets the level of a simulated GPIO pin",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "The GPIO pin",
+                        "name": "pin",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SystemResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Sets a simulated GPIO pin high or low, or pulses it high for pulsemillis.  Only available when using the sim GPIO driver",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sim"
+                ],
+                "summary": "Sets the level of a simulated GPIO pin",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "The GPIO pin",
+                        "name": "pin",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "The action to take",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.SetSimPinRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SystemResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/status": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the current state of the service, including the depth of the fire queue",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "status"
+                ],
+                "summary": "Get the service status",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SystemResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/trigger/fire/{id}": {
             "post": {
-                "description": "Fires a trigger in the system",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Fires a trigger in the system",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "triggers"
+                ],
+                "summary": "Fires a trigger in the system",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The trigger id to fire",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SystemResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/triggers": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List all triggers in the system",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "triggers"
+                ],
+                "summary": "List all triggers in the system",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SystemResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Replace all of the settings of a trigger.  Settings that aren't included are cleared.  Use PATCH to change only some settings",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "triggers"
+                ],
+                "summary": "Replace a trigger",
+                "parameters": [
+                    {
+                        "description": "The trigger to update.  Must include trigger.id when using /triggers",
+                        "name": "trigger",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.UpdateTriggerRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SystemResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create a new trigger",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "triggers"
+                ],
+                "summary": "Create a new trigger",
+                "parameters": [
+                    {
+                        "description": "The trigger to create",
+                        "name": "trigger",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.CreateTriggerRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SystemResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/triggers/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get a single trigger",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "triggers"
+                ],
+                "summary": "Get a single trigger",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The trigger id to get",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SystemResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Replace all of the settings of a trigger.  Settings that aren't included are cleared.  Use PATCH to change only some settings",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "triggers"
+                ],
+                "summary": "Replace a trigger",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The trigger id to replace.  If the body also has an id, they must match",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "The trigger to update.  Must include trigger.id when using /triggers",
+                        "name": "trigger",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.UpdateTriggerRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SystemResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Deletes a trigger in the system",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "triggers"
+                ],
+                "summary": "Deletes a trigger in the system",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The trigger id to delete",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SystemResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Change some settings of a trigger using a JSON merge patch (RFC 7396).  Only the settings included are changed, and settings set to null are cleared",
                 "consumes": [
                     "application/json"
                 ],
@@ -32,14 +840,23 @@ const docTemplate = `{
                 "tags": [
                     "triggers"
                 ],
-                "summary": "Fires a trigger in the system",
+                "summary": "Change some settings of a trigger",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "The trigger id to fire",
+                        "description": "The trigger id to change",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "The settings to change, like {\\",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
                     }
                 ],
                 "responses": {
@@ -55,33 +872,16 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
-                    }
-                }
-            }
-        },
-        "/triggers": {
-            "get": {
-                "description": "List all triggers in the system",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "triggers"
-                ],
-                "summary": "List all triggers in the system",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
-                            "$ref": "#/definitions/api.SystemResponse"
+                            "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
                     "500": {
@@ -91,9 +891,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Update a trigger",
+            }
+        },
+        "/triggers/{id}/disable": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Disable a trigger, so it's not monitored and doesn't fire (except with the fire endpoint)",
                 "consumes": [
                     "application/json"
                 ],
@@ -103,16 +910,14 @@ const docTemplate = `{
                 "tags": [
                     "triggers"
                 ],
-                "summary": "Update a trigger",
+                "summary": "Disable a trigger",
                 "parameters": [
                     {
-                        "description": "The trigger to update.  Must include trigger.id",
-                        "name": "trigger",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/api.UpdateTriggerRequest"
-                        }
+                        "type": "string",
+                        "description": "The trigger id to disable",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -122,8 +927,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.SystemResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
@@ -135,9 +940,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/triggers/{id}/enable": {
             "post": {
-                "description": "Create a new trigger",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Enable a trigger, so it's monitored and fires again",
                 "consumes": [
                     "application/json"
                 ],
@@ -147,16 +959,14 @@ const docTemplate = `{
                 "tags": [
                     "triggers"
                 ],
-                "summary": "Create a new trigger",
+                "summary": "Enable a trigger",
                 "parameters": [
                     {
-                        "description": "The trigger to create",
-                        "name": "trigger",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/api.CreateTriggerRequest"
-                        }
+                        "type": "string",
+                        "description": "The trigger id to enable",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -166,8 +976,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.SystemResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
@@ -181,9 +997,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/triggers/{id}": {
-            "delete": {
-                "description": "Deletes a trigger in the system",
+        "/triggers/{id}/history": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List firing history for a single trigger (newest first)",
                 "consumes": [
                     "application/json"
                 ],
@@ -191,16 +1012,40 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "triggers"
+                    "history"
                 ],
-                "summary": "Deletes a trigger in the system",
+                "summary": "List firing history for a single trigger",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "The trigger id to delete",
+                        "description": "The trigger id",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include items at or after this time (RFC3339)",
+                        "name": "start",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include items before this time (RFC3339)",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "The number of items to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "The maximum number of items to return (default 100)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -221,29 +1066,77 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
-                    },
-                    "503": {
-                        "description": "Service Unavailable",
-                        "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
-                        }
                     }
                 }
             }
         }
     },
     "definitions": {
+        "api.CreateAPIKeyRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "description": "What the key is used for",
+                    "type": "string"
+                },
+                "scopes": {
+                    "description": "What the key allows: read, write, fire or admin",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
         "api.CreateTriggerRequest": {
             "type": "object",
             "properties": {
+                "activelow": {
+                    "description": "If true, the pin is active when it reads low",
+                    "type": "boolean"
+                },
+                "debouncems": {
+                    "description": "If set, a pin change only counts once the pin has stayed at the new level for this long (in milliseconds)",
+                    "type": "integer"
+                },
                 "description": {
                     "description": "Additional information about the trigger",
                     "type": "string"
                 },
+                "doublepressms": {
+                    "description": "Button triggers: how long (in milliseconds) to wait for a second press",
+                    "type": "integer"
+                },
+                "edge": {
+                    "description": "The pin edge(s) that fire the trigger: rising, falling or both.  Defaults to rising",
+                    "type": "string"
+                },
+                "eventwebhooks": {
+                    "description": "Webhooks to send for specific events (like 'deactivated') instead of the trigger webhooks",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "$ref": "#/definitions/data.WebHook"
+                        }
+                    }
+                },
                 "gpiopin": {
                     "description": "The GPIO pin the sensor or button is on",
                     "type": "integer"
                 },
+                "holdrepeatms": {
+                    "description": "Button triggers: how often (in milliseconds) the hold event repeats while the button is held",
+                    "type": "integer"
+                },
+                "ignorednd": {
+                    "description": "If true, the trigger fires even when do not disturb is active",
+                    "type": "boolean"
+                },
+                "longpressms": {
+                    "description": "Button triggers: how long (in milliseconds) the button is held before it's a long press",
+                    "type": "integer"
+                },
                 "minimumsecondsbeforeretrigger": {
                     "description": "Minimum time (in seconds) before a retrigger",
                     "type": "integer"
@@ -252,6 +1145,30 @@ const docTemplate = `{
                     "description": "The trigger name",
                     "type": "string"
                 },
+                "pull": {
+                    "description": "The internal pull resistor for the pin: up, down or off",
+                    "type": "string"
+                },
+                "sampleintervalms": {
+                    "description": "If set, the pin is sampled at this interval (in milliseconds) instead of using edge detection",
+                    "type": "integer"
+                },
+                "schedule": {
+                    "description": "Time triggers: the cron expression for when the trigger fires (like '30 7 * * 1-5' or '@hourly')",
+                    "type": "string"
+                },
+                "systemevent": {
+                    "description": "System triggers: the system event that fires the trigger: startup, shutdown, networkup, monitorfailure or deliveryfailure",
+                    "type": "string"
+                },
+                "timezone": {
+                    "description": "Time triggers: the timezone for the schedule (like 'America/New_York').  Defaults to the local timezone",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "The trigger type: Motion, Button, Time or System.  Defaults to Motion",
+                    "type": "string"
+                },
                 "webhooks": {
                     "description": "The webhooks to send when triggered",
                     "type": "array",
@@ -264,8 +1181,41 @@ const docTemplate = `{
         "api.ErrorResponse": {
             "type": "object",
             "properties": {
+                "errors": {
+                    "description": "Each invalid field (for invalid requests)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.FieldError"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "description": "The field (like 'gpiopin' or 'webhooks[0].url')",
+                    "type": "string"
+                },
                 "message": {
+                    "description": "What's wrong with it",
+                    "type": "string"
+                }
+            }
+        },
+        "api.SetSimPinRequest": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "The action to take: high, low or pulse",
                     "type": "string"
+                },
+                "pulsemillis": {
+                    "description": "How long (in milliseconds) to hold the pin high when pulsing",
+                    "type": "integer"
                 }
             }
         },
@@ -281,22 +1231,60 @@ const docTemplate = `{
         "api.UpdateTriggerRequest": {
             "type": "object",
             "properties": {
+                "activelow": {
+                    "description": "If true, the pin is active when it reads low",
+                    "type": "boolean"
+                },
+                "debouncems": {
+                    "description": "If set, a pin change only counts once the pin has stayed at the new level for this long (in milliseconds)",
+                    "type": "integer"
+                },
                 "description": {
                     "description": "Additional information about the trigger",
                     "type": "string"
                 },
+                "doublepressms": {
+                    "description": "Button triggers: how long (in milliseconds) to wait for a second press",
+                    "type": "integer"
+                },
+                "edge": {
+                    "description": "The pin edge(s) that fire the trigger: rising, falling or both",
+                    "type": "string"
+                },
                 "enabled": {
                     "description": "Trigger enabled or not",
                     "type": "boolean"
                 },
+                "eventwebhooks": {
+                    "description": "Webhooks to send for specific events (like 'deactivated') instead of the trigger webhooks",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "$ref": "#/definitions/data.WebHook"
+                        }
+                    }
+                },
                 "gpiopin": {
                     "description": "The GPIO pin the sensor or button is on",
                     "type": "integer"
                 },
+                "holdrepeatms": {
+                    "description": "Button triggers: how often (in milliseconds) the hold event repeats while the button is held",
+                    "type": "integer"
+                },
                 "id": {
                     "description": "Unique Trigger ID",
                     "type": "string"
                 },
+                "ignorednd": {
+                    "description": "If true, the trigger fires even when do not disturb is active",
+                    "type": "boolean"
+                },
+                "longpressms": {
+                    "description": "Button triggers: how long (in milliseconds) the button is held before it's a long press",
+                    "type": "integer"
+                },
                 "minimumsecondsbeforeretrigger": {
                     "description": "Minimum time (in seconds) before a retrigger",
                     "type": "integer"
@@ -305,6 +1293,30 @@ const docTemplate = `{
                     "description": "The trigger name",
                     "type": "string"
                 },
+                "pull": {
+                    "description": "The internal pull resistor for the pin: up, down or off",
+                    "type": "string"
+                },
+                "sampleintervalms": {
+                    "description": "If set, the pin is sampled at this interval (in milliseconds) instead of using edge detection",
+                    "type": "integer"
+                },
+                "schedule": {
+                    "description": "Time triggers: the cron expression for when the trigger fires (like '30 7 * * 1-5' or '@hourly')",
+                    "type": "string"
+                },
+                "systemevent": {
+                    "description": "System triggers: the system event that fires the trigger: startup, shutdown, networkup, monitorfailure or deliveryfailure",
+                    "type": "string"
+                },
+                "timezone": {
+                    "description": "Time triggers: the timezone for the schedule (like 'America/New_York').  Defaults to the local timezone",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "The trigger type: Motion, Button, Time or System.  Defaults to Motion",
+                    "type": "string"
+                },
                 "webhooks": {
                     "description": "The webhooks to send when triggered",
                     "type": "array",
@@ -314,6 +1326,54 @@ const docTemplate = `{
                 }
             }
         },
+        "data.DNDSettings": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "description": "Use the do not disturb windows",
+                    "type": "boolean"
+                },
+                "mode": {
+                    "description": "What happens to fires during do not disturb: suppress (drop them) or queue (send them when it ends)",
+                    "type": "string"
+                },
+                "override": {
+                    "description": "Overrides the windows: on (do not disturb now) or off (don't).  If not set, the windows are used",
+                    "type": "string"
+                },
+                "overrideuntil": {
+                    "description": "When the override ends.  If not set, it lasts until it's changed",
+                    "type": "string"
+                },
+                "windows": {
+                    "description": "The do not disturb windows",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/data.DNDWindow"
+                    }
+                }
+            }
+        },
+        "data.DNDWindow": {
+            "type": "object",
+            "properties": {
+                "days": {
+                    "description": "The days of the week the window starts on (like 'mon' or 'saturday').  If not set, every day",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "end": {
+                    "description": "The end time (like '6:00am' or '06:00')",
+                    "type": "string"
+                },
+                "start": {
+                    "description": "The start time (like '8:00pm' or '20:00')",
+                    "type": "string"
+                }
+            }
+        },
         "data.WebHook": {
             "type": "object",
             "properties": {
@@ -324,6 +1384,10 @@ const docTemplate = `{
                         "type": "integer"
                     }
                 },
+                "contenttype": {
+                    "description": "The content type of the body.  Defaults to application/json",
+                    "type": "string"
+                },
                 "headers": {
                     "description": "The HTTP headers to send",
                     "type": "object",
@@ -331,12 +1395,54 @@ const docTemplate = `{
                         "type": "string"
                     }
                 },
+                "initialbackoff": {
+                    "description": "Delay (in milliseconds) before the first retry.  Defaults to 500",
+                    "type": "integer"
+                },
+                "maxbackoff": {
+                    "description": "Maximum delay (in milliseconds) between retries.  Defaults to 30000",
+                    "type": "integer"
+                },
+                "maxretries": {
+                    "description": "The maximum number of retries after the first attempt",
+                    "type": "integer"
+                },
+                "method": {
+                    "description": "The HTTP verb to use: GET, PUT, PATCH, DELETE or POST.  Defaults to POST",
+                    "type": "string"
+                },
+                "retryon": {
+                    "description": "What to retry on: network, 4xx, 5xx.  Defaults to network and 5xx",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "secret": {
+                    "description": "The secret used to sign the webhook.  Defaults to the global webhook secret (if there is one)",
+                    "type": "string"
+                },
+                "secretset": {
+                    "description": "True if the webhook has a secret (the API never returns it).  When changing a trigger, keeps the saved secret if secret isn't set",
+                    "type": "boolean"
+                },
+                "timeout": {
+                    "description": "Timeout (in milliseconds) for each attempt.  Defaults to 10000",
+                    "type": "integer"
+                },
                 "url": {
                     "description": "The URL to connect to",
                     "type": "string"
                 }
             }
         }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        }
     }
 }`
 
@@ -0,0 +1,18 @@
+package apiscope
+
+const (
+	// Read allows viewing triggers, history, metrics and settings
+	Read = "read"
+
+	// Write allows creating, changing and removing triggers and changing settings
+	Write = "write"
+
+	// Fire allows firing triggers
+	Fire = "fire"
+
+	// Admin allows everything, including managing API keys
+	Admin = "admin"
+)
+
+// All are all of the API key scopes
+var All = []string{Read, Write, Fire, Admin}
@@ -0,0 +1,120 @@
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// Reloader provides the TLS settings for a server from certificate files, and can reload
+// the files (for example, when a certificate is renewed) without restarting the server
+type Reloader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	mutex     sync.RWMutex
+}
+
+// NewReloader loads the server certificate and key.  If clientCAFile is set, clients
+// must present a certificate signed by one of the certificate authorities in it (mutual TLS)
+func NewReloader(certFile, keyFile, clientCAFile string) (*Reloader, error) {
+	retval := &Reloader{
+		certFile:     certFile,
+		keyFile:      keyFile,
+		clientCAFile: clientCAFile,
+	}
+
+	if err := retval.Reload(); err != nil {
+		return nil, err
+	}
+
+	return retval, nil
+}
+
+// Reload loads the certificate files again.  If they can't be loaded, an error
+// is returned and the certificates that were already loaded are kept
+func (r *Reloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("problem loading the TLS certificate and key: %v", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if r.clientCAFile != "" {
+		pem, err := os.ReadFile(r.clientCAFile)
+		if err != nil {
+			return fmt.Errorf("problem reading the client CA bundle: %v", err)
+		}
+
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("the client CA bundle %v doesn't have any certificates", r.clientCAFile)
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.cert = &cert
+	r.clientCAs = clientCAs
+
+	return nil
+}
+
+// TLSConfig returns the TLS settings for the server.  Each connection uses the
+// certificates that are loaded when it starts
+func (r *Reloader) TLSConfig() *tls.Config {
+	config := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: r.getCertificate,
+	}
+
+	//	Client certificates are checked against the CA bundle that's loaded when the connection
+	//	starts (ClientCAs can't be changed once the server is using the config)
+	if r.clientCAFile != "" {
+		config.ClientAuth = tls.RequireAnyClientCert
+		config.VerifyConnection = r.verifyClient
+	}
+
+	return config
+}
+
+// verifyClient makes sure the client certificate was issued by one of the client certificate authorities
+func (r *Reloader) verifyClient(state tls.ConnectionState) error {
+	if len(state.PeerCertificates) == 0 {
+		return fmt.Errorf("a client certificate is required")
+	}
+
+	r.mutex.RLock()
+	clientCAs := r.clientCAs
+	r.mutex.RUnlock()
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{
+		Roots:         clientCAs,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		return fmt.Errorf("invalid client certificate: %v", err)
+	}
+
+	return nil
+}
+
+// getCertificate returns the server certificate that's loaded
+func (r *Reloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.cert, nil
+}
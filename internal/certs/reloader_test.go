@@ -0,0 +1,287 @@
+package certs_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/danesparza/fxtrigger/internal/certs"
+)
+
+// testCA is a certificate authority for tests
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+// newTestCA creates a certificate authority
+func newTestCA(t *testing.T) testCA {
+	t.Helper()
+
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Unit test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate failed: %s", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+
+	return testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue creates a certificate (and key) signed by the CA and returns them as PEM
+func (ca testCA) issue(t *testing.T, serial int64, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	t.Helper()
+
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		DNSNames:     []string{"localhost"},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("CreateCertificate failed: %s", err)
+	}
+
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// writeFile writes a test file and returns its path
+func writeFile(t *testing.T, dir, name string, contents []byte) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, contents, 0600); err != nil {
+		t.Fatalf("WriteFile failed: %s", err)
+	}
+	return path
+}
+
+// servedSerial returns the serial number of the certificate the reloader serves
+func servedSerial(t *testing.T, reloader *certs.Reloader) int64 {
+	t.Helper()
+
+	served, err := reloader.TLSConfig().GetCertificate(&tls.ClientHelloInfo{})
+	if err != nil {
+		t.Fatalf("GetCertificate failed: %s", err)
+	}
+
+	cert, _ := x509.ParseCertificate(served.Certificate[0])
+	return cert.SerialNumber.Int64()
+}
+
+func TestReloader_Reload_NewCertificate_Served(t *testing.T) {
+
+	//	Arrange
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certPEM, keyPEM := ca.issue(t, 10, x509.ExtKeyUsageServerAuth)
+	certFile := writeFile(t, dir, "server.crt", certPEM)
+	keyFile := writeFile(t, dir, "server.key", keyPEM)
+
+	reloader, err := certs.NewReloader(certFile, keyFile, "")
+	if err != nil {
+		t.Fatalf("NewReloader failed: %s", err)
+	}
+
+	renewedPEM, renewedKeyPEM := ca.issue(t, 11, x509.ExtKeyUsageServerAuth)
+	writeFile(t, dir, "server.crt", renewedPEM)
+	writeFile(t, dir, "server.key", renewedKeyPEM)
+
+	//	Act
+	err = reloader.Reload()
+
+	//	Assert
+	if err != nil {
+		t.Fatalf("Reload failed: %s", err)
+	}
+
+	if serial := servedSerial(t, reloader); serial != 11 {
+		t.Errorf("Reload failed: Should have served the renewed certificate but got serial: %v", serial)
+	}
+}
+
+func TestReloader_Reload_InvalidFiles_KeepsCertificate(t *testing.T) {
+
+	//	Arrange
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certPEM, keyPEM := ca.issue(t, 10, x509.ExtKeyUsageServerAuth)
+	certFile := writeFile(t, dir, "server.crt", certPEM)
+	keyFile := writeFile(t, dir, "server.key", keyPEM)
+
+	reloader, err := certs.NewReloader(certFile, keyFile, "")
+	if err != nil {
+		t.Fatalf("NewReloader failed: %s", err)
+	}
+
+	writeFile(t, dir, "server.crt", []byte("not a certificate"))
+
+	//	Act
+	err = reloader.Reload()
+
+	//	Assert
+	if err == nil {
+		t.Errorf("Reload failed: Should have returned an error for an invalid certificate")
+	}
+
+	if serial := servedSerial(t, reloader); serial != 10 {
+		t.Errorf("Reload failed: Should have kept the old certificate but got serial: %v", serial)
+	}
+}
+
+func TestReloader_TLSConfig_ClientCA_RequiresClientCertificate(t *testing.T) {
+
+	//	Arrange
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certPEM, keyPEM := ca.issue(t, 10, x509.ExtKeyUsageServerAuth)
+	clientPEM, clientKeyPEM := ca.issue(t, 20, x509.ExtKeyUsageClientAuth)
+
+	reloader, err := certs.NewReloader(
+		writeFile(t, dir, "server.crt", certPEM),
+		writeFile(t, dir, "server.key", keyPEM),
+		writeFile(t, dir, "ca.crt", ca.pem),
+	)
+	if err != nil {
+		t.Fatalf("NewReloader failed: %s", err)
+	}
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}))
+	ts.TLS = reloader.TLSConfig()
+	ts.StartTLS()
+	defer ts.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	clientCert, _ := tls.X509KeyPair(clientPEM, clientKeyPEM)
+
+	withoutCert := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{ServerName: "localhost", RootCAs: roots}}}
+	withCert := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{ServerName: "localhost", RootCAs: roots, Certificates: []tls.Certificate{clientCert}}}}
+
+	//	Act
+	_, withoutCertErr := withoutCert.Get(ts.URL)
+	resp, withCertErr := withCert.Get(ts.URL)
+
+	//	Assert
+	if withoutCertErr == nil {
+		t.Errorf("TLSConfig failed: Should have refused a client without a certificate")
+	}
+
+	if withCertErr != nil {
+		t.Fatalf("TLSConfig failed: Should have accepted a client with a certificate but got: %s", withCertErr)
+	}
+	resp.Body.Close()
+}
+
+func TestReloader_TLSConfig_ClientCA_NegotiatesHTTP2(t *testing.T) {
+
+	//	Arrange
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certPEM, keyPEM := ca.issue(t, 10, x509.ExtKeyUsageServerAuth)
+	clientPEM, clientKeyPEM := ca.issue(t, 20, x509.ExtKeyUsageClientAuth)
+
+	reloader, err := certs.NewReloader(
+		writeFile(t, dir, "server.crt", certPEM),
+		writeFile(t, dir, "server.key", keyPEM),
+		writeFile(t, dir, "ca.crt", ca.pem),
+	)
+	if err != nil {
+		t.Fatalf("NewReloader failed: %s", err)
+	}
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}))
+	ts.EnableHTTP2 = true
+	ts.TLS = reloader.TLSConfig()
+	ts.StartTLS()
+	defer ts.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	clientCert, _ := tls.X509KeyPair(clientPEM, clientKeyPEM)
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{ServerName: "localhost", RootCAs: roots, Certificates: []tls.Certificate{clientCert}},
+		ForceAttemptHTTP2: true,
+	}}
+
+	//	Act
+	resp, err := client.Get(ts.URL)
+
+	//	Assert
+	if err != nil {
+		t.Fatalf("TLSConfig failed: Should have accepted a client with a certificate but got: %s", err)
+	}
+	resp.Body.Close()
+
+	if resp.ProtoMajor != 2 {
+		t.Errorf("TLSConfig failed: Should have used HTTP/2 but got: %v", resp.Proto)
+	}
+}
+
+func TestReloader_TLSConfig_ClientFromOtherCA_Refused(t *testing.T) {
+
+	//	Arrange
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	other := newTestCA(t)
+	certPEM, keyPEM := ca.issue(t, 10, x509.ExtKeyUsageServerAuth)
+	clientPEM, clientKeyPEM := other.issue(t, 20, x509.ExtKeyUsageClientAuth)
+
+	reloader, err := certs.NewReloader(
+		writeFile(t, dir, "server.crt", certPEM),
+		writeFile(t, dir, "server.key", keyPEM),
+		writeFile(t, dir, "ca.crt", ca.pem),
+	)
+	if err != nil {
+		t.Fatalf("NewReloader failed: %s", err)
+	}
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}))
+	ts.TLS = reloader.TLSConfig()
+	ts.StartTLS()
+	defer ts.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	clientCert, _ := tls.X509KeyPair(clientPEM, clientKeyPEM)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{ServerName: "localhost", RootCAs: roots, Certificates: []tls.Certificate{clientCert}}}}
+
+	//	Act
+	_, err = client.Get(ts.URL)
+
+	//	Assert
+	if err == nil {
+		t.Errorf("TLSConfig failed: Should have refused a client certificate from another CA")
+	}
+}
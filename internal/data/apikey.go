@@ -0,0 +1,176 @@
+package data
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/xid"
+	"github.com/tidwall/buntdb"
+)
+
+// APIKey is a key that can be used to call the REST API.  Only a hash of the key itself is stored
+type APIKey struct {
+	ID      string    `json:"id"`      // Unique API key ID
+	Name    string    `json:"name"`    // What the key is used for
+	Scopes  []string  `json:"scopes"`  // What the key allows (see apiscope)
+	Created time.Time `json:"created"` // API key create time
+	Hash    string    `json:"hash"`    // The SHA-256 hash of the key secret
+}
+
+// ErrInvalidAPIKey is returned when an API key doesn't exist (or doesn't match)
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// apiKeyPrefix starts every API key (so they're easy to spot)
+const apiKeyPrefix = "fxt"
+
+// apiKeySecretBytes is the number of random bytes in an API key secret
+const apiKeySecretBytes = 32
+
+// CreateAPIKey creates a new API key and returns it, along with the key itself.
+// The key is only available now -- just its hash is stored
+func (store Manager) CreateAPIKey(name string, scopes []string) (APIKey, string, error) {
+
+	//	Our return item
+	retval := APIKey{}
+
+	//	Generate the secret
+	secret := make([]byte, apiKeySecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return retval, "", fmt.Errorf("problem generating the API key: %s", err)
+	}
+
+	newKey := APIKey{
+		ID:      xid.New().String(), // Generate a new id
+		Name:    name,
+		Scopes:  scopes,
+		Created: time.Now(),
+		Hash:    hashAPIKeySecret(hex.EncodeToString(secret)),
+	}
+
+	//	The key includes the id, so it can be looked up
+	key := strings.Join([]string{apiKeyPrefix, newKey.ID, hex.EncodeToString(secret)}, "_")
+
+	//	Serialize to JSON format
+	encoded, err := json.Marshal(newKey)
+	if err != nil {
+		return retval, "", fmt.Errorf("problem serializing the data: %s", err)
+	}
+
+	//	Save it to the database:
+	err = store.systemdb.Update(func(tx *buntdb.Tx) error {
+		_, _, err := tx.Set(GetKey("APIKey", newKey.ID), string(encoded), &buntdb.SetOptions{})
+		return err
+	})
+
+	//	If there was an error saving the data, report it:
+	if err != nil {
+		return retval, "", fmt.Errorf("problem saving the API key: %s", err)
+	}
+
+	//	Return our data:
+	return newKey, key, nil
+}
+
+// GetAPIKey gets an API key based on its id
+func (store Manager) GetAPIKey(id string) (APIKey, error) {
+	//	Our return item
+	retval := APIKey{}
+
+	//	Find the item:
+	err := store.systemdb.View(func(tx *buntdb.Tx) error {
+		val, err := tx.Get(GetKey("APIKey", id))
+		if err != nil {
+			return err
+		}
+
+		return json.Unmarshal([]byte(val), &retval)
+	})
+
+	//	If there was an error, report it:
+	if err != nil {
+		return retval, fmt.Errorf("problem getting the API key: %s", err)
+	}
+
+	//	Return our data:
+	return retval, nil
+}
+
+// GetAllAPIKeys gets all API keys, oldest first
+func (store Manager) GetAllAPIKeys() ([]APIKey, error) {
+	//	Our return item
+	retval := []APIKey{}
+
+	//	Iterate over our values:
+	err := store.systemdb.View(func(tx *buntdb.Tx) error {
+		return tx.AscendKeys(GetKey("APIKey", "*"), func(key, val string) bool {
+			item := APIKey{}
+			if err := json.Unmarshal([]byte(val), &item); err != nil {
+				return true
+			}
+
+			retval = append(retval, item)
+			return true
+		})
+	})
+
+	//	If there was an error, report it:
+	if err != nil {
+		return retval, fmt.Errorf("problem getting the list of API keys: %s", err)
+	}
+
+	//	Return our data:
+	return retval, nil
+}
+
+// DeleteAPIKey deletes an API key
+func (store Manager) DeleteAPIKey(id string) error {
+
+	//	Remove it from the database:
+	err := store.systemdb.Update(func(tx *buntdb.Tx) error {
+		_, err := tx.Delete(GetKey("APIKey", id))
+		return err
+	})
+
+	//	If there was an error removing the data, report it:
+	if err != nil {
+		return fmt.Errorf("problem removing the API key: %s", err)
+	}
+
+	return nil
+}
+
+// AuthenticateAPIKey returns the API key for a key.  If the key doesn't exist
+// (or doesn't match), ErrInvalidAPIKey is returned
+func (store Manager) AuthenticateAPIKey(key string) (APIKey, error) {
+
+	//	Keys look like fxt_<id>_<secret>
+	parts := strings.Split(key, "_")
+	if len(parts) != 3 || parts[0] != apiKeyPrefix {
+		return APIKey{}, ErrInvalidAPIKey
+	}
+
+	apiKey, err := store.GetAPIKey(parts[1])
+	if err != nil {
+		return APIKey{}, ErrInvalidAPIKey
+	}
+
+	if subtle.ConstantTimeCompare([]byte(apiKey.Hash), []byte(hashAPIKeySecret(parts[2]))) != 1 {
+		return APIKey{}, ErrInvalidAPIKey
+	}
+
+	return apiKey, nil
+}
+
+// hashAPIKeySecret returns the hash of an API key secret.  The secrets are long and random,
+// so a fast hash is fine (there's nothing to gain from guessing them one hash at a time)
+func hashAPIKeySecret(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
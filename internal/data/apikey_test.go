@@ -0,0 +1,108 @@
+package data_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	data2 "github.com/danesparza/fxtrigger/internal/data"
+)
+
+func TestAPIKey_CreateAPIKey_AuthenticateAPIKey_Successful(t *testing.T) {
+
+	//	Arrange
+	systemdb := getTestFiles()
+
+	db, err := data2.NewManager(systemdb)
+	if err != nil {
+		t.Fatalf("NewManager failed: %s", err)
+	}
+	defer func() {
+		db.Close()
+		os.RemoveAll(systemdb)
+	}()
+
+	created, key, err := db.CreateAPIKey("Show control", []string{"read", "fire"})
+	if err != nil {
+		t.Fatalf("CreateAPIKey failed: %s", err)
+	}
+
+	//	Act
+	authenticated, err := db.AuthenticateAPIKey(key)
+
+	//	Assert
+	if err != nil {
+		t.Fatalf("AuthenticateAPIKey failed: %s", err)
+	}
+
+	if authenticated.ID != created.ID || len(authenticated.Scopes) != 2 {
+		t.Errorf("AuthenticateAPIKey failed: Should have returned the created key but got: %+v", authenticated)
+	}
+
+	if strings.Contains(created.Hash, strings.Split(key, "_")[2]) {
+		t.Errorf("CreateAPIKey failed: Should only store a hash of the key")
+	}
+}
+
+func TestAPIKey_AuthenticateAPIKey_WrongSecret_Invalid(t *testing.T) {
+
+	//	Arrange
+	systemdb := getTestFiles()
+
+	db, err := data2.NewManager(systemdb)
+	if err != nil {
+		t.Fatalf("NewManager failed: %s", err)
+	}
+	defer func() {
+		db.Close()
+		os.RemoveAll(systemdb)
+	}()
+
+	created, _, err := db.CreateAPIKey("Show control", []string{"read"})
+	if err != nil {
+		t.Fatalf("CreateAPIKey failed: %s", err)
+	}
+
+	//	Act
+	_, wrongSecret := db.AuthenticateAPIKey("fxt_" + created.ID + "_0000")
+	_, malformed := db.AuthenticateAPIKey("not-a-key")
+
+	//	Assert
+	if wrongSecret != data2.ErrInvalidAPIKey || malformed != data2.ErrInvalidAPIKey {
+		t.Errorf("AuthenticateAPIKey failed: Should have returned ErrInvalidAPIKey but got: %v, %v", wrongSecret, malformed)
+	}
+}
+
+func TestAPIKey_DeleteAPIKey_NoLongerAuthenticates(t *testing.T) {
+
+	//	Arrange
+	systemdb := getTestFiles()
+
+	db, err := data2.NewManager(systemdb)
+	if err != nil {
+		t.Fatalf("NewManager failed: %s", err)
+	}
+	defer func() {
+		db.Close()
+		os.RemoveAll(systemdb)
+	}()
+
+	created, key, err := db.CreateAPIKey("Show control", []string{"read"})
+	if err != nil {
+		t.Fatalf("CreateAPIKey failed: %s", err)
+	}
+
+	//	Act
+	err = db.DeleteAPIKey(created.ID)
+	_, authErr := db.AuthenticateAPIKey(key)
+	keys, _ := db.GetAllAPIKeys()
+
+	//	Assert
+	if err != nil {
+		t.Errorf("DeleteAPIKey failed: %s", err)
+	}
+
+	if authErr != data2.ErrInvalidAPIKey || len(keys) != 0 {
+		t.Errorf("DeleteAPIKey failed: Should have removed the key but got: %v, %v key(s)", authErr, len(keys))
+	}
+}
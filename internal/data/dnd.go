@@ -0,0 +1,81 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/tidwall/buntdb"
+)
+
+// DNDSettings are the 'do not disturb' settings.  While do not disturb is active,
+// triggers fired by pins and schedules don't send their webhooks
+type DNDSettings struct {
+	Enabled       bool        `json:"enabled"`                 // Use the do not disturb windows
+	Mode          string      `json:"mode"`                    // What happens to fires during do not disturb: suppress (drop them) or queue (send them when it ends)
+	Windows       []DNDWindow `json:"windows"`                 // The do not disturb windows
+	Override      string      `json:"override,omitempty"`      // Overrides the windows: on (do not disturb now) or off (don't).  If not set, the windows are used
+	OverrideUntil *time.Time  `json:"overrideuntil,omitempty"` // When the override ends.  If not set, it lasts until it's changed
+}
+
+// DNDWindow is a do not disturb window.  A window that ends before it starts runs overnight
+type DNDWindow struct {
+	Start string   `json:"start"`          // The start time (like '8:00pm' or '20:00')
+	End   string   `json:"end"`            // The end time (like '6:00am' or '06:00')
+	Days  []string `json:"days,omitempty"` // The days of the week the window starts on (like 'mon' or 'saturday').  If not set, every day
+}
+
+// dndKey is the key the do not disturb settings are stored under
+var dndKey = GetKey("Settings", "DND")
+
+// GetDNDSettings gets the do not disturb settings.  If they haven't been saved yet,
+// found is false and the default (disabled) settings are returned
+func (store Manager) GetDNDSettings() (settings DNDSettings, found bool, err error) {
+
+	//	Find the item:
+	err = store.systemdb.View(func(tx *buntdb.Tx) error {
+
+		val, err := tx.Get(dndKey)
+		if err == buntdb.ErrNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		found = true
+		return json.Unmarshal([]byte(val), &settings)
+	})
+
+	//	If there was an error, report it:
+	if err != nil {
+		return DNDSettings{}, false, fmt.Errorf("problem getting the do not disturb settings: %s", err)
+	}
+
+	//	Return our data:
+	return settings, found, nil
+}
+
+// SaveDNDSettings saves the do not disturb settings
+func (store Manager) SaveDNDSettings(settings DNDSettings) (DNDSettings, error) {
+
+	//	Serialize to JSON format
+	encoded, err := json.Marshal(settings)
+	if err != nil {
+		return DNDSettings{}, fmt.Errorf("problem serializing the data: %s", err)
+	}
+
+	//	Save it to the database:
+	err = store.systemdb.Update(func(tx *buntdb.Tx) error {
+		_, _, err := tx.Set(dndKey, string(encoded), &buntdb.SetOptions{})
+		return err
+	})
+
+	//	If there was an error saving the data, report it:
+	if err != nil {
+		return DNDSettings{}, fmt.Errorf("problem saving the do not disturb settings: %s", err)
+	}
+
+	//	Return our data:
+	return settings, nil
+}
@@ -0,0 +1,73 @@
+package data_test
+
+import (
+	"os"
+	"testing"
+
+	data2 "github.com/danesparza/fxtrigger/internal/data"
+)
+
+func TestDND_GetDNDSettings_NotSaved_NotFound(t *testing.T) {
+
+	//	Arrange
+	systemdb := getTestFiles()
+
+	db, err := data2.NewManager(systemdb)
+	if err != nil {
+		t.Fatalf("NewManager failed: %s", err)
+	}
+	defer func() {
+		db.Close()
+		os.RemoveAll(systemdb)
+	}()
+
+	//	Act
+	settings, found, err := db.GetDNDSettings()
+
+	//	Assert
+	if err != nil {
+		t.Errorf("GetDNDSettings - Should get settings without error, but got: %s", err)
+	}
+
+	if found || settings.Enabled {
+		t.Errorf("GetDNDSettings failed: Should return disabled settings when none are saved but got: %+v", settings)
+	}
+}
+
+func TestDND_SaveDNDSettings_ValidSettings_Successful(t *testing.T) {
+
+	//	Arrange
+	systemdb := getTestFiles()
+
+	db, err := data2.NewManager(systemdb)
+	if err != nil {
+		t.Fatalf("NewManager failed: %s", err)
+	}
+	defer func() {
+		db.Close()
+		os.RemoveAll(systemdb)
+	}()
+
+	testSettings := data2.DNDSettings{
+		Enabled: true,
+		Mode:    "queue",
+		Windows: []data2.DNDWindow{{Start: "8:00pm", End: "6:00am", Days: []string{"fri", "sat"}}},
+	}
+
+	//	Act
+	_, err = db.SaveDNDSettings(testSettings)
+	gotSettings, found, _ := db.GetDNDSettings()
+
+	//	Assert
+	if err != nil {
+		t.Errorf("SaveDNDSettings - Should save settings without error, but got: %s", err)
+	}
+
+	if !found || !gotSettings.Enabled || gotSettings.Mode != "queue" {
+		t.Errorf("SaveDNDSettings failed: Should have saved the settings but got: %+v", gotSettings)
+	}
+
+	if len(gotSettings.Windows) != 1 || len(gotSettings.Windows[0].Days) != 2 {
+		t.Errorf("SaveDNDSettings failed: Should have saved the window but got: %+v", gotSettings.Windows)
+	}
+}
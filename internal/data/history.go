@@ -0,0 +1,142 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/rs/xid"
+	"github.com/tidwall/buntdb"
+)
+
+// HistoryItem is a record of a trigger firing
+type HistoryItem struct {
+	ID          string           `json:"id"`                   // Unique history item ID
+	TriggerID   string           `json:"triggerid"`            // The trigger that fired
+	TriggerName string           `json:"triggername"`          // The trigger name at the time it fired
+	Source      string           `json:"source"`               // What caused the trigger to fire (see triggersource)
+	Event       string           `json:"event"`                // The trigger event (see triggerevent)
+	Timestamp   time.Time        `json:"timestamp"`            // When the trigger fired
+	WebHooks    []DeliveryResult `json:"webhooks"`             // The outcome of each webhook
+	Suppressed  bool             `json:"suppressed,omitempty"` // The webhooks weren't sent because do not disturb was active
+}
+
+// DeliveryResult is the outcome of sending a single webhook
+type DeliveryResult struct {
+	URL             string `json:"url"`                // The URL the webhook was sent to
+	StatusCode      int    `json:"statuscode"`         // The HTTP status code returned.  Zero if no response was received
+	DurationMillis  int64  `json:"durationms"`         // How long (in milliseconds) the last attempt took
+	Attempts        int    `json:"attempts"`           // The number of attempts made
+	Error           string `json:"error,omitempty"`    // The error (if any) sending the webhook
+	ResponseSnippet string `json:"response,omitempty"` // The first part of the response body
+}
+
+// Succeeded returns true if the webhook was delivered and got a 2xx response
+func (result DeliveryResult) Succeeded() bool {
+	return result.Error == "" && result.StatusCode >= 200 && result.StatusCode < 300
+}
+
+// HistoryFilter narrows the history items returned
+type HistoryFilter struct {
+	TriggerID string    // Only include items for this trigger (optional)
+	Start     time.Time // Only include items at or after this time (optional)
+	End       time.Time // Only include items before this time (optional)
+	Offset    int       // The number of matching items to skip
+	Limit     int       // The maximum number of items to return.  Zero means no limit
+}
+
+// AddHistory adds a history item to the system.  If ttl is greater than zero
+// the item will automatically expire after that amount of time
+func (store Manager) AddHistory(item HistoryItem, ttl time.Duration) (HistoryItem, error) {
+
+	//	Our return item
+	retval := HistoryItem{}
+
+	//	Generate a new (time sortable) id
+	item.ID = xid.New().String()
+
+	//	Serialize to JSON format
+	encoded, err := json.Marshal(item)
+	if err != nil {
+		return retval, fmt.Errorf("problem serializing the data: %s", err)
+	}
+
+	//	Set the expiration (if we have one)
+	opts := &buntdb.SetOptions{}
+	if ttl > 0 {
+		opts.Expires = true
+		opts.TTL = ttl
+	}
+
+	//	Save it to the database:
+	err = store.systemdb.Update(func(tx *buntdb.Tx) error {
+		_, _, err := tx.Set(GetKey("History", item.ID), string(encoded), opts)
+		return err
+	})
+
+	//	If there was an error saving the data, report it:
+	if err != nil {
+		return retval, fmt.Errorf("problem saving the history item: %s", err)
+	}
+
+	//	Set our retval:
+	retval = item
+
+	//	Return our data:
+	return retval, nil
+}
+
+// GetHistory gets history items in the system (newest first) that match the filter
+func (store Manager) GetHistory(filter HistoryFilter) ([]HistoryItem, error) {
+	//	Our return item
+	retval := []HistoryItem{}
+
+	//	Track how many matching items we've skipped
+	skipped := 0
+
+	//	Iterate over our values.  History ids are time sortable, so descending keys are newest first:
+	err := store.systemdb.View(func(tx *buntdb.Tx) error {
+		return tx.DescendKeys(GetKey("History", "*"), func(key, val string) bool {
+
+			//	Create our item:
+			item := HistoryItem{}
+
+			//	Unmarshal data into our item
+			if err := json.Unmarshal([]byte(val), &item); err != nil {
+				return false
+			}
+
+			//	Apply the filter
+			if filter.TriggerID != "" && item.TriggerID != filter.TriggerID {
+				return true
+			}
+
+			if !filter.Start.IsZero() && item.Timestamp.Before(filter.Start) {
+				return true
+			}
+
+			if !filter.End.IsZero() && !item.Timestamp.Before(filter.End) {
+				return true
+			}
+
+			//	Apply paging
+			if skipped < filter.Offset {
+				skipped++
+				return true
+			}
+
+			retval = append(retval, item)
+
+			//	Keep going until we hit the limit (if we have one)
+			return filter.Limit < 1 || len(retval) < filter.Limit
+		})
+	})
+
+	//	If there was an error, report it:
+	if err != nil {
+		return retval, fmt.Errorf("problem getting the list of history items: %s", err)
+	}
+
+	//	Return our data:
+	return retval, nil
+}
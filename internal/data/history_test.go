@@ -0,0 +1,119 @@
+package data_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	data2 "github.com/danesparza/fxtrigger/internal/data"
+)
+
+func TestHistory_AddHistory_ValidItem_Successful(t *testing.T) {
+
+	//	Arrange
+	systemdb := getTestFiles()
+
+	db, err := data2.NewManager(systemdb)
+	if err != nil {
+		t.Fatalf("NewManager failed: %s", err)
+	}
+	defer func() {
+		db.Close()
+		os.RemoveAll(systemdb)
+	}()
+
+	testItem := data2.HistoryItem{
+		TriggerID: "trigger1",
+		Source:    "GPIO",
+		Timestamp: time.Now(),
+		WebHooks:  []data2.DeliveryResult{{URL: "http://www.github.com/webhook1", StatusCode: 200}},
+	}
+
+	//	Act
+	newItem, err := db.AddHistory(testItem, time.Hour)
+
+	//	Assert
+	if err != nil {
+		t.Errorf("AddHistory - Should add history without error, but got: %s", err)
+	}
+
+	if len(newItem.ID) < 2 {
+		t.Errorf("AddHistory failed: Should have set a valid id but got: %v", newItem.ID)
+	}
+}
+
+func TestHistory_GetHistory_FilterByTrigger_Successful(t *testing.T) {
+
+	//	Arrange
+	systemdb := getTestFiles()
+
+	db, err := data2.NewManager(systemdb)
+	if err != nil {
+		t.Fatalf("NewManager failed: %s", err)
+	}
+	defer func() {
+		db.Close()
+		os.RemoveAll(systemdb)
+	}()
+
+	db.AddHistory(data2.HistoryItem{TriggerID: "trigger1", Source: "GPIO", Timestamp: time.Now()}, 0)
+	db.AddHistory(data2.HistoryItem{TriggerID: "trigger2", Source: "API", Timestamp: time.Now()}, 0)
+	db.AddHistory(data2.HistoryItem{TriggerID: "trigger1", Source: "API", Timestamp: time.Now()}, 0)
+
+	//	Act
+	gotItems, err := db.GetHistory(data2.HistoryFilter{TriggerID: "trigger1"})
+
+	//	Assert
+	if err != nil {
+		t.Errorf("GetHistory - Should get history without error, but got: %s", err)
+	}
+
+	if len(gotItems) != 2 {
+		t.Fatalf("GetHistory failed: Should get only the items for the trigger but got: %v", len(gotItems))
+	}
+
+	if gotItems[0].Source != "API" {
+		t.Errorf("GetHistory failed: Should get the newest item first but got: %+v", gotItems[0])
+	}
+}
+
+func TestHistory_GetHistory_TimeRangeAndPaging_Successful(t *testing.T) {
+
+	//	Arrange
+	systemdb := getTestFiles()
+
+	db, err := data2.NewManager(systemdb)
+	if err != nil {
+		t.Fatalf("NewManager failed: %s", err)
+	}
+	defer func() {
+		db.Close()
+		os.RemoveAll(systemdb)
+	}()
+
+	now := time.Now()
+	for i := 5; i > 0; i-- {
+		db.AddHistory(data2.HistoryItem{TriggerID: "trigger1", Timestamp: now.Add(time.Duration(-i) * time.Hour)}, 0)
+	}
+
+	//	Act
+	gotItems, err := db.GetHistory(data2.HistoryFilter{
+		Start:  now.Add(-4 * time.Hour),
+		End:    now.Add(-1 * time.Hour),
+		Offset: 1,
+		Limit:  1,
+	})
+
+	//	Assert
+	if err != nil {
+		t.Errorf("GetHistory - Should get history without error, but got: %s", err)
+	}
+
+	if len(gotItems) != 1 {
+		t.Fatalf("GetHistory failed: Should get a single page of items but got: %v", len(gotItems))
+	}
+
+	if !gotItems[0].Timestamp.Equal(now.Add(-3 * time.Hour)) {
+		t.Errorf("GetHistory failed: Should get the second newest item in range but got: %+v", gotItems[0])
+	}
+}
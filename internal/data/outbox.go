@@ -0,0 +1,129 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/rs/xid"
+	"github.com/tidwall/buntdb"
+)
+
+// OutboxItem is a pending webhook delivery.  It's saved before the webhook is sent
+// and removed once the delivery is finished, so deliveries interrupted by a restart can be replayed
+type OutboxItem struct {
+	ID          string    `json:"id"`          // Unique outbox item ID
+	FireID      string    `json:"fireid"`      // Groups the deliveries from a single trigger firing
+	TriggerID   string    `json:"triggerid"`   // The trigger that fired
+	TriggerName string    `json:"triggername"` // The trigger name at the time it fired
+	Source      string    `json:"source"`      // What caused the trigger to fire (see triggersource)
+	Event       string    `json:"event"`       // The trigger event (see triggerevent)
+	Timestamp   time.Time `json:"timestamp"`   // When the trigger fired
+	WebHook     WebHook   `json:"webhook"`     // The webhook to deliver
+	HookURL     string    `json:"hookurl"`     // The webhook URL before its templates were rendered (delivery metrics are tracked by it)
+}
+
+// AddOutboxItems adds pending deliveries for a single trigger firing to the outbox.
+// All the items are given the same fire id
+func (store Manager) AddOutboxItems(items []OutboxItem) ([]OutboxItem, error) {
+	return store.addOutboxItems(xid.New().String(), items)
+}
+
+// MoveQueuedFireToOutbox adds the pending deliveries for a queued fire to the outbox and removes
+// the queued fire, all at once (so the fire is never lost or processed twice).  The items are
+// given the queued fire's id as their fire id
+func (store Manager) MoveQueuedFireToOutbox(queuedFireID string, items []OutboxItem) ([]OutboxItem, error) {
+	return store.addOutboxItems(queuedFireID, items, GetKey("QueuedFire", queuedFireID))
+}
+
+// addOutboxItems saves the items to the outbox with the fire id, and removes the other keys
+func (store Manager) addOutboxItems(fireID string, items []OutboxItem, removeKeys ...string) ([]OutboxItem, error) {
+
+	//	Our return items
+	retval := []OutboxItem{}
+
+	//	Generate the ids
+	for _, item := range items {
+		item.ID = xid.New().String()
+		item.FireID = fireID
+		retval = append(retval, item)
+	}
+
+	//	Save them to the database (all or nothing):
+	err := store.systemdb.Update(func(tx *buntdb.Tx) error {
+		for _, item := range retval {
+			encoded, err := json.Marshal(item)
+			if err != nil {
+				return fmt.Errorf("problem serializing the data: %s", err)
+			}
+
+			if _, _, err := tx.Set(GetKey("Outbox", item.ID), string(encoded), &buntdb.SetOptions{}); err != nil {
+				return err
+			}
+		}
+
+		for _, key := range removeKeys {
+			if _, err := tx.Delete(key); err != nil && err != buntdb.ErrNotFound {
+				return err
+			}
+		}
+		return nil
+	})
+
+	//	If there was an error saving the data, report it:
+	if err != nil {
+		return []OutboxItem{}, fmt.Errorf("problem saving the outbox items: %s", err)
+	}
+
+	//	Return our data:
+	return retval, nil
+}
+
+// GetAllOutboxItems gets all pending deliveries in the outbox (oldest first)
+func (store Manager) GetAllOutboxItems() ([]OutboxItem, error) {
+	//	Our return item
+	retval := []OutboxItem{}
+
+	//	Iterate over our values.  Outbox ids are time sortable, so ascending keys are oldest first:
+	err := store.systemdb.View(func(tx *buntdb.Tx) error {
+		return tx.AscendKeys(GetKey("Outbox", "*"), func(key, val string) bool {
+
+			//	Create our item:
+			item := OutboxItem{}
+
+			//	Unmarshal data into our item
+			if err := json.Unmarshal([]byte(val), &item); err != nil {
+				return false
+			}
+
+			retval = append(retval, item)
+			return true
+		})
+	})
+
+	//	If there was an error, report it:
+	if err != nil {
+		return retval, fmt.Errorf("problem getting the list of outbox items: %s", err)
+	}
+
+	//	Return our data:
+	return retval, nil
+}
+
+// DeleteOutboxItem removes a finished delivery from the outbox
+func (store Manager) DeleteOutboxItem(id string) error {
+
+	//	Remove it from the database:
+	err := store.systemdb.Update(func(tx *buntdb.Tx) error {
+		_, err := tx.Delete(GetKey("Outbox", id))
+		return err
+	})
+
+	//	If there was an error removing the data, report it:
+	if err != nil {
+		return fmt.Errorf("problem removing the outbox item: %s", err)
+	}
+
+	//	Return our data:
+	return nil
+}
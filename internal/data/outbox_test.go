@@ -0,0 +1,83 @@
+package data_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	data2 "github.com/danesparza/fxtrigger/internal/data"
+)
+
+func TestOutbox_AddOutboxItems_ValidItems_Successful(t *testing.T) {
+
+	//	Arrange
+	systemdb := getTestFiles()
+
+	db, err := data2.NewManager(systemdb)
+	if err != nil {
+		t.Fatalf("NewManager failed: %s", err)
+	}
+	defer func() {
+		db.Close()
+		os.RemoveAll(systemdb)
+	}()
+
+	testItems := []data2.OutboxItem{
+		{TriggerID: "trigger1", Timestamp: time.Now(), WebHook: data2.WebHook{URL: "http://www.github.com/webhook1"}},
+		{TriggerID: "trigger1", Timestamp: time.Now(), WebHook: data2.WebHook{URL: "http://www.microsoft.com/webhook2"}},
+	}
+
+	//	Act
+	newItems, err := db.AddOutboxItems(testItems)
+	gotItems, _ := db.GetAllOutboxItems()
+
+	//	Assert
+	if err != nil {
+		t.Errorf("AddOutboxItems - Should add items without error, but got: %s", err)
+	}
+
+	if len(gotItems) != 2 {
+		t.Fatalf("AddOutboxItems failed: Should have saved both items but got: %v", len(gotItems))
+	}
+
+	if newItems[0].FireID == "" || newItems[0].FireID != newItems[1].FireID {
+		t.Errorf("AddOutboxItems failed: Should have grouped the items with the same fire id but got: %+v", newItems)
+	}
+
+	if gotItems[0].WebHook.URL != testItems[0].WebHook.URL {
+		t.Errorf("AddOutboxItems failed: Should get the oldest item first but got: %+v", gotItems[0])
+	}
+}
+
+func TestOutbox_DeleteOutboxItem_ValidItem_Successful(t *testing.T) {
+
+	//	Arrange
+	systemdb := getTestFiles()
+
+	db, err := data2.NewManager(systemdb)
+	if err != nil {
+		t.Fatalf("NewManager failed: %s", err)
+	}
+	defer func() {
+		db.Close()
+		os.RemoveAll(systemdb)
+	}()
+
+	newItems, _ := db.AddOutboxItems([]data2.OutboxItem{
+		{TriggerID: "trigger1", WebHook: data2.WebHook{URL: "http://www.github.com/webhook1"}},
+		{TriggerID: "trigger1", WebHook: data2.WebHook{URL: "http://www.microsoft.com/webhook2"}},
+	})
+
+	//	Act
+	err = db.DeleteOutboxItem(newItems[0].ID)
+	gotItems, _ := db.GetAllOutboxItems()
+
+	//	Assert
+	if err != nil {
+		t.Errorf("DeleteOutboxItem - Should delete item without error, but got: %s", err)
+	}
+
+	if len(gotItems) != 1 || gotItems[0].ID != newItems[1].ID {
+		t.Errorf("DeleteOutboxItem failed: Should only have the remaining item but got: %+v", gotItems)
+	}
+}
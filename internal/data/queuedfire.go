@@ -0,0 +1,100 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/rs/xid"
+	"github.com/tidwall/buntdb"
+)
+
+// QueuedFire is a trigger fire waiting to be processed.  It's saved when the trigger fires and removed
+// once its deliveries are in the outbox, so fires still waiting when the service stops are processed at startup
+type QueuedFire struct {
+	ID        string    `json:"id"`               // Unique queued fire ID
+	Trigger   Trigger   `json:"trigger"`          // The trigger that fired
+	Source    string    `json:"source"`           // What caused the trigger to fire (see triggersource)
+	Event     string    `json:"event"`            // The trigger event (see triggerevent)
+	Timestamp time.Time `json:"timestamp"`        // When the trigger fired
+	Level     string    `json:"level,omitempty"`  // The pin level when the trigger fired (high or low)
+	Detail    string    `json:"detail,omitempty"` // Details about a system event
+}
+
+// AddQueuedFire saves a trigger fire that's waiting to be processed.  The id is generated
+func (store Manager) AddQueuedFire(fire QueuedFire) (QueuedFire, error) {
+
+	fire.ID = xid.New().String() // Generate a new id
+
+	//	Serialize to JSON format
+	encoded, err := json.Marshal(fire)
+	if err != nil {
+		return QueuedFire{}, fmt.Errorf("problem serializing the data: %s", err)
+	}
+
+	//	Save it to the database:
+	err = store.systemdb.Update(func(tx *buntdb.Tx) error {
+		_, _, err := tx.Set(GetKey("QueuedFire", fire.ID), string(encoded), &buntdb.SetOptions{})
+		return err
+	})
+
+	//	If there was an error saving the data, report it:
+	if err != nil {
+		return QueuedFire{}, fmt.Errorf("problem saving the queued fire: %s", err)
+	}
+
+	//	Return our data:
+	return fire, nil
+}
+
+// GetAllQueuedFires gets all the trigger fires waiting to be processed (oldest first)
+func (store Manager) GetAllQueuedFires() ([]QueuedFire, error) {
+	//	Our return item
+	retval := []QueuedFire{}
+
+	//	Iterate over our values.  Queued fire ids are time sortable, so ascending keys are oldest first:
+	err := store.systemdb.View(func(tx *buntdb.Tx) error {
+		return tx.AscendKeys(GetKey("QueuedFire", "*"), func(key, val string) bool {
+
+			//	Create our item:
+			item := QueuedFire{}
+
+			//	Unmarshal data into our item
+			if err := json.Unmarshal([]byte(val), &item); err != nil {
+				return false
+			}
+
+			retval = append(retval, item)
+			return true
+		})
+	})
+
+	//	If there was an error, report it:
+	if err != nil {
+		return retval, fmt.Errorf("problem getting the list of queued fires: %s", err)
+	}
+
+	//	Return our data:
+	return retval, nil
+}
+
+// DeleteQueuedFire removes a trigger fire that's been processed (or dropped)
+func (store Manager) DeleteQueuedFire(id string) error {
+
+	//	Remove it from the database:
+	err := store.systemdb.Update(func(tx *buntdb.Tx) error {
+		_, err := tx.Delete(GetKey("QueuedFire", id))
+		if err == buntdb.ErrNotFound {
+			return nil
+		}
+		return err
+	})
+
+	//	If there was an error removing the data, report it:
+	if err != nil {
+		return fmt.Errorf("problem removing the queued fire: %s", err)
+	}
+
+	//	Return our data:
+	return nil
+}
@@ -0,0 +1,109 @@
+package data_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	data2 "github.com/danesparza/fxtrigger/internal/data"
+)
+
+func TestQueuedFire_AddQueuedFire_ValidFire_Successful(t *testing.T) {
+
+	//	Arrange
+	systemdb := getTestFiles()
+
+	db, err := data2.NewManager(systemdb)
+	if err != nil {
+		t.Fatalf("NewManager failed: %s", err)
+	}
+	defer func() {
+		db.Close()
+		os.RemoveAll(systemdb)
+	}()
+
+	//	Act
+	first, err := db.AddQueuedFire(data2.QueuedFire{Trigger: data2.Trigger{ID: "trigger1"}, Event: "activated", Timestamp: time.Now()})
+	db.AddQueuedFire(data2.QueuedFire{Trigger: data2.Trigger{ID: "trigger2"}, Event: "activated", Timestamp: time.Now()})
+	gotFires, _ := db.GetAllQueuedFires()
+
+	//	Assert
+	if err != nil {
+		t.Errorf("AddQueuedFire - Should add the fire without error, but got: %s", err)
+	}
+
+	if first.ID == "" {
+		t.Errorf("AddQueuedFire failed: Should have generated an id but got: %+v", first)
+	}
+
+	if len(gotFires) != 2 || gotFires[0].Trigger.ID != "trigger1" {
+		t.Errorf("GetAllQueuedFires failed: Should get both fires (oldest first) but got: %+v", gotFires)
+	}
+}
+
+func TestQueuedFire_MoveQueuedFireToOutbox_ValidFire_Moved(t *testing.T) {
+
+	//	Arrange
+	systemdb := getTestFiles()
+
+	db, err := data2.NewManager(systemdb)
+	if err != nil {
+		t.Fatalf("NewManager failed: %s", err)
+	}
+	defer func() {
+		db.Close()
+		os.RemoveAll(systemdb)
+	}()
+
+	fire, _ := db.AddQueuedFire(data2.QueuedFire{Trigger: data2.Trigger{ID: "trigger1"}, Timestamp: time.Now()})
+
+	//	Act
+	items, err := db.MoveQueuedFireToOutbox(fire.ID, []data2.OutboxItem{
+		{TriggerID: "trigger1", WebHook: data2.WebHook{URL: "http://www.github.com/webhook1"}},
+	})
+	gotFires, _ := db.GetAllQueuedFires()
+	gotItems, _ := db.GetAllOutboxItems()
+
+	//	Assert
+	if err != nil {
+		t.Errorf("MoveQueuedFireToOutbox - Should move the fire without error, but got: %s", err)
+	}
+
+	if len(gotFires) != 0 {
+		t.Errorf("MoveQueuedFireToOutbox failed: Should have removed the queued fire but got: %+v", gotFires)
+	}
+
+	if len(gotItems) != 1 || items[0].FireID != fire.ID {
+		t.Errorf("MoveQueuedFireToOutbox failed: Should have added the deliveries with the fire id but got: %+v", gotItems)
+	}
+}
+
+func TestQueuedFire_DeleteQueuedFire_ValidFire_Successful(t *testing.T) {
+
+	//	Arrange
+	systemdb := getTestFiles()
+
+	db, err := data2.NewManager(systemdb)
+	if err != nil {
+		t.Fatalf("NewManager failed: %s", err)
+	}
+	defer func() {
+		db.Close()
+		os.RemoveAll(systemdb)
+	}()
+
+	fire, _ := db.AddQueuedFire(data2.QueuedFire{Trigger: data2.Trigger{ID: "trigger1"}, Timestamp: time.Now()})
+
+	//	Act
+	err = db.DeleteQueuedFire(fire.ID)
+	gotFires, _ := db.GetAllQueuedFires()
+
+	//	Assert
+	if err != nil {
+		t.Errorf("DeleteQueuedFire - Should remove the fire without error, but got: %s", err)
+	}
+
+	if len(gotFires) != 0 {
+		t.Errorf("DeleteQueuedFire failed: Should have removed the fire but got: %+v", gotFires)
+	}
+}
@@ -11,41 +11,79 @@ import (
 
 // Trigger represents sensor/button trigger information.
 type Trigger struct {
-	ID                            string    `json:"id"`                            // Unique Trigger ID
-	Enabled                       bool      `json:"enabled"`                       // Trigger enabled or not
-	Created                       time.Time `json:"created"`                       // Trigger create time
-	Name                          string    `json:"name"`                          // The trigger name
-	Description                   string    `json:"description"`                   // Additional information about the trigger
-	GPIOPin                       int       `json:"gpiopin"`                       // The GPIO pin the sensor or button is on
-	WebHooks                      []WebHook `json:"webhooks"`                      // The webhooks to send when triggered
-	MinimumSecondsBeforeRetrigger int       `json:"minimumsecondsbeforeretrigger"` // Minimum time (in seconds) before a retrigger
+	ID                            string               `json:"id"`                            // Unique Trigger ID
+	Enabled                       bool                 `json:"enabled"`                       // Trigger enabled or not
+	Created                       time.Time            `json:"created"`                       // Trigger create time
+	Name                          string               `json:"name"`                          // The trigger name
+	Description                   string               `json:"description"`                   // Additional information about the trigger
+	Type                          string               `json:"type,omitempty"`                // The trigger type (see triggertype).  Defaults to Motion
+	GPIOPin                       int                  `json:"gpiopin"`                       // The GPIO pin the sensor or button is on
+	Pull                          string               `json:"pull,omitempty"`                // The internal pull resistor for the pin: up, down or off.  If not set, the pull resistor isn't changed
+	ActiveLow                     bool                 `json:"activelow"`                     // If true, the pin is active when it reads low (for example: a button wired to ground with a pull up)
+	SampleIntervalMillis          int                  `json:"sampleintervalms,omitempty"`    // If set, the pin is sampled at this interval (in milliseconds) instead of using edge detection.  Only use this if edge detection doesn't work with your hardware
+	DebounceMillis                int                  `json:"debouncems,omitempty"`          // If set, a pin change only counts once the pin has stayed at the new level for this long (in milliseconds)
+	Edge                          string               `json:"edge,omitempty"`                // The pin edge(s) that fire the trigger: rising (activated), falling (deactivated) or both.  Defaults to rising
+	LongPressMillis               int                  `json:"longpressms,omitempty"`         // Button triggers: how long (in milliseconds) the button is held before it's a long press.  If not set, long presses aren't detected
+	DoublePressMillis             int                  `json:"doublepressms,omitempty"`       // Button triggers: how long (in milliseconds) to wait for a second press.  If not set, double presses aren't detected
+	HoldRepeatMillis              int                  `json:"holdrepeatms,omitempty"`        // Button triggers: how often (in milliseconds) the hold event repeats while the button is held.  If not set, hold events aren't sent
+	Schedule                      string               `json:"schedule,omitempty"`            // Time triggers: the cron expression for when the trigger fires (like '30 7 * * 1-5' or '@hourly')
+	SystemEvent                   string               `json:"systemevent,omitempty"`         // System triggers: the system event that fires the trigger: startup, shutdown, networkup, monitorfailure or deliveryfailure
+	Timezone                      string               `json:"timezone,omitempty"`            // Time triggers: the timezone for the schedule (like 'America/New_York').  Defaults to the local timezone
+	WebHooks                      []WebHook            `json:"webhooks"`                      // The webhooks to send when triggered
+	EventWebHooks                 map[string][]WebHook `json:"eventwebhooks,omitempty"`       // Webhooks to send for specific events (like 'deactivated') instead of the trigger webhooks
+	MinimumSecondsBeforeRetrigger int                  `json:"minimumsecondsbeforeretrigger"` // Minimum time (in seconds) before a retrigger
+	IgnoreDND                     bool                 `json:"ignorednd"`                     // If true, the trigger fires even when do not disturb is active
+}
+
+// WebHooksFor returns the webhooks to send for an event.  If the event
+// doesn't have its own webhooks, the trigger webhooks are used
+func (trigger Trigger) WebHooksFor(event string) []WebHook {
+	if hooks, exists := trigger.EventWebHooks[event]; exists && len(hooks) > 0 {
+		return hooks
+	}
+	return trigger.WebHooks
 }
 
 // WebHook represents a notification message sent to an endpoint
-// It's always content type: application/json
-// It's always HTTP verb POST
+// The content type defaults to application/json
+// The HTTP verb defaults to POST
+// The URL, header values and body are Go text/template templates rendered each time the trigger fires
 type WebHook struct {
-	URL     string            `json:"url"`               // The URL to connect to
-	Headers map[string]string `json:"headers,omitempty"` // The HTTP headers to send
-	Body    []byte            `json:"body,omitempty"`    // The HTTP body to send.  This can be empty
+	URL            string            `json:"url"`                      // The URL to connect to
+	Method         string            `json:"method,omitempty"`         // The HTTP verb to use: GET, PUT, PATCH, DELETE or POST.  Defaults to POST
+	Headers        map[string]string `json:"headers,omitempty"`        // The HTTP headers to send
+	ContentType    string            `json:"contenttype,omitempty"`    // The content type of the body.  Defaults to application/json
+	Body           []byte            `json:"body,omitempty"`           // The HTTP body to send.  This can be empty
+	MaxRetries     int               `json:"maxretries,omitempty"`     // The maximum number of retries after the first attempt
+	InitialBackoff int               `json:"initialbackoff,omitempty"` // Delay (in milliseconds) before the first retry.  Defaults to 500
+	MaxBackoff     int               `json:"maxbackoff,omitempty"`     // Maximum delay (in milliseconds) between retries.  Defaults to 30000
+	RetryOn        []string          `json:"retryon,omitempty"`        // What to retry on: network, 4xx, 5xx.  Defaults to network and 5xx
+	Timeout        int               `json:"timeout,omitempty"`        // Timeout (in milliseconds) for each attempt.  Defaults to 10000
+	Secret         string            `json:"secret,omitempty"`         // The secret used to sign the webhook.  Defaults to the global webhook secret (if there is one)
+	SecretSet      bool              `json:"secretset"`                // True if the webhook has a secret (the API never returns it).  When changing a trigger, keeps the saved secret if secret isn't set
 }
 
 // AddTrigger adds a trigger to the system
 func (store Manager) AddTrigger(name, description string, gpiopin int, webhooks []WebHook, minimumsleep int) (Trigger, error) {
-
-	//	Our return item
-	retval := Trigger{}
-
-	newTrigger := Trigger{
-		ID:                            xid.New().String(), // Generate a new id
-		Created:                       time.Now(),
-		Enabled:                       true,
+	return store.CreateTrigger(Trigger{
 		Name:                          name,
 		Description:                   description,
 		GPIOPin:                       gpiopin,
 		WebHooks:                      webhooks,
 		MinimumSecondsBeforeRetrigger: minimumsleep,
-	}
+	})
+}
+
+// CreateTrigger adds a trigger to the system with all of the passed settings.
+// The id and create time are generated, and the trigger is enabled
+func (store Manager) CreateTrigger(newTrigger Trigger) (Trigger, error) {
+
+	//	Our return item
+	retval := Trigger{}
+
+	newTrigger.ID = xid.New().String() // Generate a new id
+	newTrigger.Created = time.Now()
+	newTrigger.Enabled = true
 
 	//	Serialize to JSON format
 	encoded, err := json.Marshal(newTrigger)
@@ -71,7 +109,7 @@ func (store Manager) AddTrigger(name, description string, gpiopin int, webhooks
 	return retval, nil
 }
 
-// AddTrigger adds a trigger to the system
+// UpdateTrigger updates a trigger in the system
 func (store Manager) UpdateTrigger(updatedTrigger Trigger) (Trigger, error) {
 
 	//	Our return item
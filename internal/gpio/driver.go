@@ -0,0 +1,113 @@
+package gpio
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MaxPin is the highest GPIO (BCM) pin on the Raspberry Pi 40 pin header
+const MaxPin = 27
+
+// Level is the logic level of a pin
+type Level uint8
+
+// Edge is a pin level transition that can be detected
+type Edge uint8
+
+// Pull is the internal pull resistor setting of a pin
+type Pull uint8
+
+// Pin levels
+const (
+	Low Level = iota
+	High
+)
+
+// Edge events
+const (
+	NoEdge Edge = iota
+	RiseEdge
+	FallEdge
+	AnyEdge = RiseEdge | FallEdge
+)
+
+// Pull resistor settings
+const (
+	PullOff Pull = iota
+	PullDown
+	PullUp
+)
+
+// String returns the display name of the level: high or low
+func (level Level) String() string {
+	if level == High {
+		return "high"
+	}
+	return "low"
+}
+
+// Edge names used in trigger settings
+const (
+	RisingEdgeName  = "rising"
+	FallingEdgeName = "falling"
+	BothEdgeName    = "both"
+)
+
+// ParseEdge parses an edge name (rising, falling or both).  An empty name is a rising edge
+func ParseEdge(name string) (Edge, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", RisingEdgeName:
+		return RiseEdge, nil
+	case FallingEdgeName:
+		return FallEdge, nil
+	case BothEdgeName:
+		return AnyEdge, nil
+	}
+	return NoEdge, fmt.Errorf("edge must be one of: %s, %s, %s", RisingEdgeName, FallingEdgeName, BothEdgeName)
+}
+
+// Pull names used in trigger settings
+const (
+	PullUpName   = "up"
+	PullDownName = "down"
+	PullOffName  = "off"
+)
+
+// ParsePull parses a pull resistor name (up, down or off)
+func ParsePull(name string) (Pull, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case PullUpName:
+		return PullUp, nil
+	case PullDownName:
+		return PullDown, nil
+	case PullOffName:
+		return PullOff, nil
+	}
+	return PullOff, fmt.Errorf("pull must be one of: %s, %s, %s", PullUpName, PullDownName, PullOffName)
+}
+
+// Driver is a GPIO backend.  Pin numbers are always the
+// BCM (GPIO) number -- not the physical header pin number
+type Driver interface {
+	// Open prepares the backend for use.  It must be called once before any pin operations
+	Open() error
+
+	// Close releases the backend
+	Close() error
+
+	// Input sets the pin to input mode
+	Input(pin int)
+
+	// Pull sets the internal pull resistor of the pin
+	Pull(pin int, pull Pull)
+
+	// Read returns the current level of the pin
+	Read(pin int) Level
+
+	// Detect subscribes the pin to edge detection.  Pass NoEdge to unsubscribe
+	Detect(pin int, edge Edge)
+
+	// EdgeDetected returns true if a subscribed edge occurred on the pin
+	// since the last call (or since detection was enabled), and clears it
+	EdgeDetected(pin int) bool
+}
@@ -0,0 +1,56 @@
+package gpio
+
+import (
+	"fmt"
+
+	"github.com/danesparza/go-rpio"
+)
+
+// RPIODriver is a Driver backed by go-rpio (Raspberry Pi /dev/gpiomem)
+type RPIODriver struct{}
+
+// NewRPIODriver creates a new Raspberry Pi GPIO driver
+func NewRPIODriver() *RPIODriver {
+	return &RPIODriver{}
+}
+
+// Open maps the GPIO memory range
+func (d *RPIODriver) Open() error {
+	if err := rpio.Open(); err != nil {
+		return fmt.Errorf("problem opening rpio: %v", err)
+	}
+	return nil
+}
+
+// Close unmaps the GPIO memory range
+func (d *RPIODriver) Close() error {
+	return rpio.Close()
+}
+
+// Input sets the pin to input mode
+func (d *RPIODriver) Input(pin int) {
+	rpio.Pin(pin).Mode(rpio.Input)
+}
+
+// Pull sets the internal pull resistor of the pin
+func (d *RPIODriver) Pull(pin int, pull Pull) {
+	rpio.Pin(pin).Pull(rpio.Pull(pull))
+}
+
+// Read returns the current level of the pin
+func (d *RPIODriver) Read(pin int) Level {
+	if rpio.Pin(pin).Read() == rpio.High {
+		return High
+	}
+	return Low
+}
+
+// Detect subscribes the pin to edge detection
+func (d *RPIODriver) Detect(pin int, edge Edge) {
+	rpio.Pin(pin).Detect(rpio.Edge(edge))
+}
+
+// EdgeDetected returns true if a subscribed edge occurred since the last call
+func (d *RPIODriver) EdgeDetected(pin int) bool {
+	return rpio.Pin(pin).EdgeDetected()
+}
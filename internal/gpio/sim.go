@@ -0,0 +1,115 @@
+package gpio
+
+import (
+	"sync"
+	"time"
+)
+
+// SimDriver is an in-memory Driver.  Pin levels are set by calling Set,
+// which makes it possible to run triggers without GPIO hardware
+type SimDriver struct {
+	mu       sync.Mutex
+	levels   map[int]Level
+	driven   map[int]bool
+	detect   map[int]Edge
+	detected map[int]bool
+}
+
+// NewSimDriver creates a new simulated GPIO driver.  All pins start low
+func NewSimDriver() *SimDriver {
+	return &SimDriver{
+		levels:   make(map[int]Level),
+		driven:   make(map[int]bool),
+		detect:   make(map[int]Edge),
+		detected: make(map[int]bool),
+	}
+}
+
+// Open is a no-op for the simulated driver
+func (d *SimDriver) Open() error {
+	return nil
+}
+
+// Close is a no-op for the simulated driver
+func (d *SimDriver) Close() error {
+	return nil
+}
+
+// Input is a no-op for the simulated driver
+func (d *SimDriver) Input(pin int) {}
+
+// Pull sets the level of a pin that hasn't been set yet: high for
+// pull up, low for pull down -- just like a floating input would read
+func (d *SimDriver) Pull(pin int, pull Pull) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.driven[pin] {
+		return
+	}
+
+	switch pull {
+	case PullUp:
+		d.levels[pin] = High
+	case PullDown:
+		d.levels[pin] = Low
+	}
+}
+
+// Read returns the current level of the pin
+func (d *SimDriver) Read(pin int) Level {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.levels[pin]
+}
+
+// Detect subscribes the pin to edge detection
+func (d *SimDriver) Detect(pin int, edge Edge) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.detect[pin] = edge
+	delete(d.detected, pin) // Clear any outdated detection
+}
+
+// EdgeDetected returns true if a subscribed edge occurred since the last call
+func (d *SimDriver) EdgeDetected(pin int) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	retval := d.detected[pin]
+	delete(d.detected, pin)
+	return retval
+}
+
+// Set sets the level of the pin, latching an edge event if the pin is subscribed to it
+func (d *SimDriver) Set(pin int, level Level) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.driven[pin] = true
+
+	current := d.levels[pin]
+	if current == level {
+		return
+	}
+	d.levels[pin] = level
+
+	edge := FallEdge
+	if level == High {
+		edge = RiseEdge
+	}
+	if d.detect[pin]&edge > 0 {
+		d.detected[pin] = true
+	}
+}
+
+// Pulse sets the pin high and then sets it back low after the duration has passed.
+// It does not block
+func (d *SimDriver) Pulse(pin int, duration time.Duration) {
+	d.Set(pin, High)
+	time.AfterFunc(duration, func() {
+		d.Set(pin, Low)
+	})
+}
@@ -0,0 +1,81 @@
+package gpio_test
+
+import (
+	"testing"
+
+	"github.com/danesparza/fxtrigger/internal/gpio"
+)
+
+func TestSim_Set_ChangesLevel_Successful(t *testing.T) {
+
+	//	Arrange
+	driver := gpio.NewSimDriver()
+	driver.Input(17)
+
+	//	Act
+	driver.Set(17, gpio.High)
+
+	//	Assert
+	if driver.Read(17) != gpio.High {
+		t.Errorf("Set failed: Pin should read high")
+	}
+
+	if driver.Read(18) != gpio.Low {
+		t.Errorf("Set failed: Other pins should still read low")
+	}
+}
+
+func TestSim_EdgeDetected_SubscribedEdge_Successful(t *testing.T) {
+
+	//	Arrange
+	driver := gpio.NewSimDriver()
+	driver.Detect(17, gpio.RiseEdge)
+
+	//	Act
+	driver.Set(17, gpio.High)
+	driver.Set(17, gpio.Low)
+
+	//	Assert
+	if !driver.EdgeDetected(17) {
+		t.Errorf("EdgeDetected failed: Should have latched the rising edge")
+	}
+
+	if driver.EdgeDetected(17) {
+		t.Errorf("EdgeDetected failed: Should have cleared the edge after reading it")
+	}
+}
+
+func TestSim_EdgeDetected_UnsubscribedEdge_NotDetected(t *testing.T) {
+
+	//	Arrange
+	driver := gpio.NewSimDriver()
+	driver.Detect(17, gpio.FallEdge)
+
+	//	Act
+	driver.Set(17, gpio.High)
+
+	//	Assert
+	if driver.EdgeDetected(17) {
+		t.Errorf("EdgeDetected failed: Should not detect a rising edge when subscribed to falling edges")
+	}
+}
+
+func TestSim_Pull_UndrivenPin_ReadsPullLevel(t *testing.T) {
+
+	//	Arrange
+	driver := gpio.NewSimDriver()
+
+	//	Act
+	driver.Pull(17, gpio.PullUp)
+	driver.Set(18, gpio.Low)
+	driver.Pull(18, gpio.PullUp)
+
+	//	Assert
+	if driver.Read(17) != gpio.High {
+		t.Errorf("Pull failed: An undriven pin with a pull up should read high")
+	}
+
+	if driver.Read(18) != gpio.Low {
+		t.Errorf("Pull failed: A driven pin should keep its level")
+	}
+}
@@ -0,0 +1,230 @@
+package trigger
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danesparza/fxtrigger/internal/data"
+	"github.com/danesparza/fxtrigger/internal/triggersource"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DNDSuppress drops fires during do not disturb
+	DNDSuppress = "suppress"
+
+	// DNDQueue holds fires during do not disturb and sends them when it ends
+	DNDQueue = "queue"
+
+	// DNDOverrideOn turns do not disturb on, regardless of the windows
+	DNDOverrideOn = "on"
+
+	// DNDOverrideOff turns do not disturb off, regardless of the windows
+	DNDOverrideOff = "off"
+)
+
+// dndCheckInterval is how often queued fires are checked to see if do not disturb has ended
+const dndCheckInterval = 1 * time.Second
+
+// ValidateDNDSettings makes sure the do not disturb settings are valid
+func ValidateDNDSettings(settings data.DNDSettings) error {
+	if settings.Mode != "" && settings.Mode != DNDSuppress && settings.Mode != DNDQueue {
+		return fmt.Errorf("mode must be one of: %v, %v", DNDSuppress, DNDQueue)
+	}
+
+	if settings.Override != "" && settings.Override != DNDOverrideOn && settings.Override != DNDOverrideOff {
+		return fmt.Errorf("override must be one of: %v, %v", DNDOverrideOn, DNDOverrideOff)
+	}
+
+	for i, window := range settings.Windows {
+		start, err := parseClock(window.Start)
+		if err != nil {
+			return fmt.Errorf("windows[%v].start: %v", i, err)
+		}
+
+		end, err := parseClock(window.End)
+		if err != nil {
+			return fmt.Errorf("windows[%v].end: %v", i, err)
+		}
+
+		if start == end {
+			return fmt.Errorf("windows[%v]: start and end can't be the same", i)
+		}
+
+		for _, day := range window.Days {
+			if _, err := parseWeekday(day); err != nil {
+				return fmt.Errorf("windows[%v].days: %v", i, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// DNDActive returns true if do not disturb is active at the given time
+func DNDActive(settings data.DNDSettings, now time.Time) bool {
+
+	//	An override (that hasn't expired) wins
+	if settings.OverrideUntil == nil || now.Before(*settings.OverrideUntil) {
+		switch settings.Override {
+		case DNDOverrideOn:
+			return true
+		case DNDOverrideOff:
+			return false
+		}
+	}
+
+	if !settings.Enabled {
+		return false
+	}
+
+	for _, window := range settings.Windows {
+		if windowActive(window, now) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// windowActive returns true if the (valid) do not disturb window is active at the given time
+func windowActive(window data.DNDWindow, now time.Time) bool {
+	start, _ := parseClock(window.Start)
+	end, _ := parseClock(window.End)
+	minute := now.Hour()*60 + now.Minute()
+
+	switch {
+	case start < end && minute >= start && minute < end:
+		return onDay(window, now.Weekday())
+	case start > end && minute >= start:
+		//	Overnight window, before midnight
+		return onDay(window, now.Weekday())
+	case start > end && minute < end:
+		//	Overnight window, after midnight.  It started the day before
+		return onDay(window, now.AddDate(0, 0, -1).Weekday())
+	}
+
+	return false
+}
+
+// onDay returns true if the window starts on the given day of the week
+func onDay(window data.DNDWindow, day time.Weekday) bool {
+	if len(window.Days) == 0 {
+		return true
+	}
+
+	for _, d := range window.Days {
+		if wd, err := parseWeekday(d); err == nil && wd == day {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseClock parses a time of day (like '8:00pm', '8pm' or '20:00') into minutes after midnight
+func parseClock(clock string) (int, error) {
+	value := strings.ToLower(strings.ReplaceAll(clock, " ", ""))
+
+	//	Look for am / pm
+	meridiem := ""
+	if strings.HasSuffix(value, "am") || strings.HasSuffix(value, "pm") {
+		meridiem = value[len(value)-2:]
+		value = value[:len(value)-2]
+	}
+
+	hourPart, minutePart, hasMinutes := strings.Cut(value, ":")
+	if !hasMinutes {
+		minutePart = "0"
+	}
+
+	hour, err := strconv.Atoi(hourPart)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", clock)
+	}
+
+	minute, err := strconv.Atoi(minutePart)
+	if err != nil || minute < 0 || minute > 59 || (hasMinutes && len(minutePart) != 2) {
+		return 0, fmt.Errorf("invalid time %q", clock)
+	}
+
+	switch meridiem {
+	case "":
+		if hour < 0 || hour > 23 || !hasMinutes {
+			return 0, fmt.Errorf("invalid time %q", clock)
+		}
+	default:
+		if hour < 1 || hour > 12 {
+			return 0, fmt.Errorf("invalid time %q", clock)
+		}
+		hour = hour % 12
+		if meridiem == "pm" {
+			hour += 12
+		}
+	}
+
+	return hour*60 + minute, nil
+}
+
+// parseWeekday parses a day of the week (like 'mon' or 'Monday')
+func parseWeekday(day string) (time.Weekday, error) {
+	value := strings.ToLower(strings.TrimSpace(day))
+
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		name := strings.ToLower(wd.String())
+		if value == name || value == name[:3] {
+			return wd, nil
+		}
+	}
+
+	return time.Sunday, fmt.Errorf("invalid day of the week %q", day)
+}
+
+// dndMode returns what to do with a fire request because of do not disturb: suppress, queue
+// or nothing (an empty string).  Only fires from pins and schedules are affected
+func (bp BackgroundProcess) dndMode(req FireRequest) string {
+	if req.Trigger.IgnoreDND || (req.Source != triggersource.GPIO && req.Source != triggersource.Schedule) {
+		return ""
+	}
+
+	settings, _, err := bp.DB.GetDNDSettings()
+	if err != nil {
+		log.Err(err).Str("TriggerID", req.Trigger.ID).Msg("Problem getting do not disturb settings.  Firing anyway")
+		return ""
+	}
+
+	if !DNDActive(settings, req.Time) {
+		return ""
+	}
+
+	if settings.Mode == DNDQueue {
+		return DNDQueue
+	}
+
+	return DNDSuppress
+}
+
+// dndActive returns true if do not disturb is active right now
+func (bp BackgroundProcess) dndActive() bool {
+	settings, _, err := bp.DB.GetDNDSettings()
+	if err != nil {
+		log.Err(err).Msg("Problem getting do not disturb settings")
+		return false
+	}
+
+	return DNDActive(settings, time.Now())
+}
+
+// queueFire adds a fire request to the do not disturb queue.  Only the latest fire for each
+// trigger event is kept, so a night of motion is sent once.  The fire it replaced (if any) is returned
+func queueFire(queued []FireRequest, req FireRequest) ([]FireRequest, *FireRequest) {
+	for i, q := range queued {
+		if q.Trigger.ID == req.Trigger.ID && q.Event == req.Event {
+			return append(append(queued[:i:i], queued[i+1:]...), req), &q
+		}
+	}
+
+	return append(queued, req), nil
+}
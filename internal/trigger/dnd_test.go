@@ -0,0 +1,165 @@
+package trigger_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/danesparza/fxtrigger/internal/data"
+	"github.com/danesparza/fxtrigger/internal/trigger"
+	"github.com/danesparza/fxtrigger/internal/triggerevent"
+	"github.com/danesparza/fxtrigger/internal/triggersource"
+)
+
+func TestDND_DNDActive_Windows(t *testing.T) {
+
+	//	Arrange
+	overnight := data.DNDSettings{
+		Enabled: true,
+		Windows: []data.DNDWindow{{Start: "8:00pm", End: "6:00am", Days: []string{"fri"}}},
+	}
+	friday := func(hour, minute int) time.Time {
+		return time.Date(2024, 6, 7, hour, minute, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		expected bool
+	}{
+		{"before the window", friday(19, 59), false},
+		{"start of the window", friday(20, 0), true},
+		{"after midnight", friday(27, 0), true},
+		{"end of the window", friday(30, 0), false},
+		{"window on another day", friday(-1, 0), false},
+	}
+
+	for _, test := range tests {
+		//	Act
+		active := trigger.DNDActive(overnight, test.now)
+
+		//	Assert
+		if active != test.expected {
+			t.Errorf("DNDActive failed for %v (%v): Expected %v but got %v", test.name, test.now, test.expected, active)
+		}
+	}
+}
+
+func TestDND_DNDActive_Override(t *testing.T) {
+
+	//	Arrange
+	now := time.Date(2024, 6, 7, 12, 0, 0, 0, time.Local)
+	earlier := now.Add(-1 * time.Hour)
+	later := now.Add(1 * time.Hour)
+
+	tests := []struct {
+		name     string
+		settings data.DNDSettings
+		expected bool
+	}{
+		{"disabled", data.DNDSettings{Windows: []data.DNDWindow{{Start: "11:00", End: "13:00"}}}, false},
+		{"override on", data.DNDSettings{Override: "on"}, true},
+		{"override on until later", data.DNDSettings{Override: "on", OverrideUntil: &later}, true},
+		{"expired override on", data.DNDSettings{Override: "on", OverrideUntil: &earlier}, false},
+		{"override off", data.DNDSettings{Enabled: true, Override: "off", Windows: []data.DNDWindow{{Start: "11:00", End: "13:00"}}}, false},
+	}
+
+	for _, test := range tests {
+		//	Act
+		active := trigger.DNDActive(test.settings, now)
+
+		//	Assert
+		if active != test.expected {
+			t.Errorf("DNDActive failed for %v: Expected %v but got %v", test.name, test.expected, active)
+		}
+	}
+}
+
+func TestDND_ValidateDNDSettings_Invalid_ReturnsError(t *testing.T) {
+
+	//	Arrange
+	tests := []data.DNDSettings{
+		{Mode: "snooze"},
+		{Override: "maybe"},
+		{Windows: []data.DNDWindow{{Start: "25:00", End: "6:00am"}}},
+		{Windows: []data.DNDWindow{{Start: "13:00pm", End: "6:00am"}}},
+		{Windows: []data.DNDWindow{{Start: "8:00pm", End: "8pm"}}},
+		{Windows: []data.DNDWindow{{Start: "8:00pm", End: "6:00am", Days: []string{"someday"}}}},
+	}
+
+	for _, test := range tests {
+		//	Act
+		err := trigger.ValidateDNDSettings(test)
+
+		//	Assert
+		if err == nil {
+			t.Errorf("ValidateDNDSettings failed: Should have returned an error for %+v", test)
+		}
+	}
+}
+
+func TestDND_HeldFires_ServiceRestarts_SentWhenDNDEnds(t *testing.T) {
+
+	//	Arrange
+	db, err := data.NewManager(filepath.Join(t.TempDir(), "system.db"))
+	if err != nil {
+		t.Fatalf("NewManager failed: %s", err)
+	}
+	defer db.Close()
+
+	hooks := make(chan string, 10)
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		hooks <- req.URL.Path
+	}))
+	defer ts.Close()
+
+	testTrigger := data.Trigger{ID: "held1", WebHooks: []data.WebHook{{URL: ts.URL + "/held"}}}
+	db.SaveDNDSettings(data.DNDSettings{Mode: trigger.DNDQueue, Override: trigger.DNDOverrideOn})
+
+	//	Two fires are held during do not disturb, then the service stops
+	ctx, cancel := context.WithCancel(context.Background())
+	bp := trigger.BackgroundProcess{
+		FireQueue: trigger.NewFireQueue(trigger.FireQueueOptions{DB: db}),
+		DB:        db,
+		Metrics:   trigger.NewDeliveryMetrics(),
+	}
+	go bp.HandleAndProcess(ctx)
+
+	for i := 0; i < 2; i++ {
+		bp.FireQueue.Enqueue(trigger.FireRequest{Trigger: testTrigger, Source: triggersource.GPIO, Event: triggerevent.Activated, Time: time.Now()})
+	}
+	bp.FireQueue.Drain(context.Background())
+	cancel()
+	held, _ := db.GetAllQueuedFires()
+
+	//	Act
+	db.SaveDNDSettings(data.DNDSettings{Mode: trigger.DNDQueue})
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	bp = trigger.BackgroundProcess{
+		FireQueue: trigger.NewFireQueue(trigger.FireQueueOptions{DB: db}),
+		DB:        db,
+		Metrics:   trigger.NewDeliveryMetrics(),
+	}
+	go bp.HandleAndProcess(ctx)
+	bp.ReplayOutbox(ctx)
+	waitForHistory(t, bp, testTrigger.ID)
+	remaining, _ := db.GetAllQueuedFires()
+
+	//	Assert
+	if len(held) != 1 {
+		t.Errorf("HandleAndProcess failed: Should have saved the latest held fire but got: %v", len(held))
+	}
+
+	if len(hooks) != 1 {
+		t.Errorf("ReplayOutbox failed: Should have sent the held fire once do not disturb ended but got: %v", len(hooks))
+	}
+
+	if len(remaining) != 0 {
+		t.Errorf("ReplayOutbox failed: Should have removed the sent fire but got: %v", len(remaining))
+	}
+}
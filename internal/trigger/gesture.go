@@ -0,0 +1,123 @@
+package trigger
+
+import (
+	"time"
+
+	"github.com/danesparza/fxtrigger/internal/data"
+	"github.com/danesparza/fxtrigger/internal/triggerevent"
+)
+
+// gestureRecognizer turns the presses and releases of a button trigger into gesture events
+// (press, double press, long press and hold).  It doesn't keep any timers itself:  after each
+// call, the caller should call tick once the deadline passes
+type gestureRecognizer struct {
+	longPress   time.Duration // If zero, long presses aren't detected
+	doublePress time.Duration // If zero, double presses aren't detected
+	holdRepeat  time.Duration // If zero, hold events aren't sent
+
+	pressed  bool      // The button is down
+	handled  bool      // The current press has already sent its event(s).  Releasing it doesn't send anything
+	held     bool      // The current press has become a long press
+	waiting  bool      // A press was released and we're waiting to see if there's a second press
+	deadline time.Time // When tick needs to be called.  Zero if nothing is pending
+}
+
+// newGestureRecognizer creates a gesture recognizer using the button settings of the trigger
+func newGestureRecognizer(t data.Trigger) *gestureRecognizer {
+	return &gestureRecognizer{
+		longPress:   time.Duration(t.LongPressMillis) * time.Millisecond,
+		doublePress: time.Duration(t.DoublePressMillis) * time.Millisecond,
+		holdRepeat:  time.Duration(t.HoldRepeatMillis) * time.Millisecond,
+	}
+}
+
+// press handles the button going down
+func (g *gestureRecognizer) press(now time.Time) []string {
+	g.pressed = true
+	g.handled = false
+	g.held = false
+	g.deadline = time.Time{}
+
+	//	Start timing a long press (or the first hold, if long presses aren't detected)
+	switch {
+	case g.longPress > 0:
+		g.deadline = now.Add(g.longPress)
+	case g.holdRepeat > 0:
+		g.deadline = now.Add(g.holdRepeat)
+	}
+
+	//	If there's nothing else this press could turn into, it's a press right away
+	if g.deadline.IsZero() && g.doublePress == 0 {
+		g.handled = true
+		return []string{triggerevent.Press}
+	}
+
+	return nil
+}
+
+// release handles the button coming back up
+func (g *gestureRecognizer) release(now time.Time) []string {
+	if !g.pressed {
+		return nil
+	}
+	g.pressed = false
+	g.deadline = time.Time{}
+
+	//	The press already sent its event(s)
+	if g.handled {
+		g.waiting = false
+		return nil
+	}
+
+	//	This was the second press
+	if g.waiting {
+		g.waiting = false
+		return []string{triggerevent.DoublePress}
+	}
+
+	//	Wait to see if there's a second press
+	if g.doublePress > 0 {
+		g.waiting = true
+		g.deadline = now.Add(g.doublePress)
+		return nil
+	}
+
+	return []string{triggerevent.Press}
+}
+
+// tick sends any events that are due at the deadline
+func (g *gestureRecognizer) tick(now time.Time) []string {
+	if g.deadline.IsZero() || now.Before(g.deadline) {
+		return nil
+	}
+	g.deadline = time.Time{}
+
+	//	The double press window ended without a second press
+	if !g.pressed {
+		g.waiting = false
+		return []string{triggerevent.Press}
+	}
+
+	var events []string
+
+	//	The second press became a long press, so the first press was a single press
+	if g.waiting {
+		g.waiting = false
+		events = append(events, triggerevent.Press)
+	}
+
+	if !g.held && g.longPress > 0 {
+		events = append(events, triggerevent.LongPress)
+	} else {
+		events = append(events, triggerevent.Hold)
+	}
+	g.held = true
+	g.handled = true
+
+	//	Keep sending hold events while the button is held
+	if g.holdRepeat > 0 {
+		g.deadline = now.Add(g.holdRepeat)
+	}
+
+	return events
+}
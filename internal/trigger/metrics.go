@@ -0,0 +1,72 @@
+package trigger
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/danesparza/fxtrigger/internal/data"
+)
+
+// DeliveryStats are webhook delivery statistics for a single URL
+type DeliveryStats struct {
+	URL            string    `json:"url"`                 // The webhook URL (before its templates are rendered)
+	Deliveries     int       `json:"deliveries"`          // The number of delivery attempts
+	Failures       int       `json:"failures"`            // The number of unsuccessful delivery attempts
+	AverageMillis  int64     `json:"averagems"`           // The average delivery time (in milliseconds)
+	LastStatusCode int       `json:"laststatuscode"`      // The HTTP status code of the last delivery
+	LastError      string    `json:"lasterror,omitempty"` // The error (if any) from the last delivery
+	LastDelivery   time.Time `json:"lastdelivery"`        // When the last delivery finished
+
+	totalMillis int64
+}
+
+// DeliveryMetrics tracks webhook delivery statistics since the service started
+type DeliveryMetrics struct {
+	stats   map[string]*DeliveryStats
+	rwMutex sync.RWMutex
+}
+
+// NewDeliveryMetrics creates a new set of delivery metrics
+func NewDeliveryMetrics() *DeliveryMetrics {
+	return &DeliveryMetrics{stats: make(map[string]*DeliveryStats)}
+}
+
+// Record adds a delivery result to the metrics
+func (m *DeliveryMetrics) Record(result data.DeliveryResult) {
+	m.rwMutex.Lock()
+	defer m.rwMutex.Unlock()
+
+	stats, exists := m.stats[result.URL]
+	if !exists {
+		stats = &DeliveryStats{URL: result.URL}
+		m.stats[result.URL] = stats
+	}
+
+	stats.Deliveries++
+	if !result.Succeeded() {
+		stats.Failures++
+	}
+	stats.totalMillis += result.DurationMillis
+	stats.AverageMillis = stats.totalMillis / int64(stats.Deliveries)
+	stats.LastStatusCode = result.StatusCode
+	stats.LastError = result.Error
+	stats.LastDelivery = time.Now()
+}
+
+// Snapshot returns a copy of the current statistics, sorted by URL
+func (m *DeliveryMetrics) Snapshot() []DeliveryStats {
+	m.rwMutex.RLock()
+	defer m.rwMutex.RUnlock()
+
+	retval := []DeliveryStats{}
+	for _, stats := range m.stats {
+		retval = append(retval, *stats)
+	}
+
+	sort.Slice(retval, func(i, j int) bool {
+		return retval[i].URL < retval[j].URL
+	})
+
+	return retval
+}
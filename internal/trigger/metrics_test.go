@@ -0,0 +1,70 @@
+package trigger_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/danesparza/fxtrigger/internal/data"
+	"github.com/danesparza/fxtrigger/internal/trigger"
+	"github.com/danesparza/fxtrigger/internal/triggersource"
+)
+
+func TestMetrics_Record_MultipleResults_Successful(t *testing.T) {
+
+	//	Arrange
+	metrics := trigger.NewDeliveryMetrics()
+
+	//	Act
+	metrics.Record(data.DeliveryResult{URL: "http://lights/cue", StatusCode: 200, DurationMillis: 10})
+	metrics.Record(data.DeliveryResult{URL: "http://lights/cue", StatusCode: 503, DurationMillis: 30})
+	metrics.Record(data.DeliveryResult{URL: "http://sound/cue", Error: "connection refused"})
+
+	gotStats := metrics.Snapshot()
+
+	//	Assert
+	if len(gotStats) != 2 {
+		t.Fatalf("Record failed: Should track stats for each url but got: %v", len(gotStats))
+	}
+
+	if gotStats[0].Deliveries != 2 || gotStats[0].Failures != 1 {
+		t.Errorf("Record failed: Should count deliveries and failures but got: %+v", gotStats[0])
+	}
+
+	if gotStats[0].AverageMillis != 20 {
+		t.Errorf("Record failed: Should average the delivery time but got: %v", gotStats[0].AverageMillis)
+	}
+
+	if gotStats[1].LastError != "connection refused" {
+		t.Errorf("Record failed: Should keep the last error but got: %+v", gotStats[1])
+	}
+}
+
+func TestMetrics_FireTrigger_TemplatedURL_TrackedOnce(t *testing.T) {
+
+	//	Arrange
+	bp := newTestProcess(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}))
+	defer ts.Close()
+
+	hookURL := ts.URL + "/cue?n={{.FireCount}}"
+	testTrigger := data.Trigger{ID: "metrics1", WebHooks: []data.WebHook{{URL: hookURL}}}
+
+	//	Act
+	for i := 0; i < 3; i++ {
+		bp.FireQueue.Enqueue(trigger.FireRequest{Trigger: testTrigger, Source: triggersource.API, Time: time.Now()})
+	}
+
+	gotStats := bp.Metrics.Snapshot()
+	for i := 0; i < 50 && (len(gotStats) != 1 || gotStats[0].Deliveries < 3); i++ {
+		time.Sleep(50 * time.Millisecond)
+		gotStats = bp.Metrics.Snapshot()
+	}
+
+	//	Assert
+	if len(gotStats) != 1 || gotStats[0].URL != hookURL || gotStats[0].Deliveries != 3 {
+		t.Errorf("Record failed: Should track the webhook once by its (unrendered) url but got: %+v", gotStats)
+	}
+}
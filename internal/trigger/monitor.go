@@ -0,0 +1,201 @@
+package trigger
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/danesparza/fxtrigger/internal/data"
+	"github.com/danesparza/fxtrigger/internal/gpio"
+	"github.com/danesparza/fxtrigger/internal/triggerevent"
+	"github.com/danesparza/fxtrigger/internal/triggersource"
+	"github.com/danesparza/fxtrigger/internal/triggertype"
+	"github.com/rs/zerolog/log"
+)
+
+// runMonitor runs the monitor for the type of trigger until the context is cancelled.
+// If the monitor stops because of an error (or panics), the error is returned
+func (bp BackgroundProcess) runMonitor(ctx context.Context, pins *pinReader, req data.Trigger) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("monitor panicked: %v", r)
+		}
+	}()
+
+	switch req.Type {
+	case triggertype.Time:
+		return bp.runSchedule(ctx, req)
+	case triggertype.System:
+		//	System triggers are fired by the service itself.  There's nothing to watch
+		<-ctx.Done()
+		return nil
+	default:
+		//	Make sure we can use the pin (another trigger might be using it with different settings)
+		release, err := bp.Pins.Claim(req)
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		bp.monitorPin(ctx, pins, req)
+		return nil
+	}
+}
+
+// monitorPin watches the pin for a trigger and fires the trigger as the pin changes.
+// It returns when the context is cancelled
+func (bp BackgroundProcess) monitorPin(ctx context.Context, pins *pinReader, req data.Trigger) {
+
+	bp.GPIO.Input(req.GPIOPin)
+
+	//	Set the pull resistor (if we've been asked to)
+	if req.Pull != "" {
+		pull, err := gpio.ParsePull(req.Pull)
+		if err != nil {
+			log.Err(err).Str("TriggerID", req.ID).Msg("Invalid trigger pull.  Not changing the pull resistor")
+		} else {
+			bp.GPIO.Pull(req.GPIOPin, pull)
+		}
+	}
+
+	//	Get the edge(s) that fire the trigger
+	edge, err := gpio.ParseEdge(req.Edge)
+	if err != nil {
+		log.Err(err).Str("TriggerID", req.ID).Msg("Invalid trigger edge.  Using rising edge")
+		edge = gpio.RiseEdge
+	}
+
+	//	Store the 'last reading'.  This is the logical (active = high) level
+	//	Initially, set it to the 'low' (no motion) state
+	lr := gpio.Low
+	lastTrigger := make(map[string]time.Time) // The last time each event fired
+
+	//	Button triggers send gestures instead of activated / deactivated events
+	var gestures *gestureRecognizer
+	if req.Type == triggertype.Button {
+		gestures = newGestureRecognizer(req)
+	}
+	gestureTimer := time.NewTimer(0)
+	stopTimer(gestureTimer)
+	defer gestureTimer.Stop()
+	raw := gpio.Low // The last (debounced) raw level
+
+	//	fire fires an event, unless the event fired too recently
+	fire := func(event string) {
+		currentTime := time.Now()
+		diff := currentTime.Sub(lastTrigger[event])
+		if diff.Seconds() > float64(req.MinimumSecondsBeforeRetrigger) {
+			//	If it's been long enough -- reset the last trigger time to now
+			//	and actually trigger the item
+			lastTrigger[event] = currentTime
+			log.Debug().Int("GPIOPin", req.GPIOPin).Str("TriggerID", req.ID).Str("Event", event).Msg("Pin changed.  Firing event")
+			bp.FireQueue.Enqueue(FireRequest{Trigger: req, Source: triggersource.GPIO, Event: event, Time: currentTime, Level: raw.String()})
+		} else {
+			log.Debug().
+				Int("GPIOPin", req.GPIOPin).
+				Str("TriggerID", req.ID).
+				Str("Event", event).
+				Int("MinimumSecondsBeforeRetrigger", req.MinimumSecondsBeforeRetrigger).
+				Msg("Pin changed, but minimum seconds threshold not met.  Not triggering.")
+		}
+	}
+
+	//	fireGestures fires the gesture events and waits for the next gesture deadline
+	fireGestures := func(events []string) {
+		for _, event := range events {
+			fire(event)
+		}
+
+		stopTimer(gestureTimer)
+		if !gestures.deadline.IsZero() {
+			gestureTimer.Reset(time.Until(gestures.deadline))
+		}
+	}
+
+	//	levelChanged handles a (debounced) raw pin level
+	levelChanged := func(level gpio.Level) {
+		raw = level
+
+		//	Active low pins are active when they read low
+		v := raw
+		if req.ActiveLow {
+			v = raw ^ gpio.High
+		}
+
+		//	Latch / unlatch check
+		if lr == v {
+			return
+		}
+		lr = v
+
+		if gestures != nil {
+			if lr == gpio.High {
+				fireGestures(gestures.press(time.Now()))
+			} else {
+				fireGestures(gestures.release(time.Now()))
+			}
+			return
+		}
+
+		//	Rising (logical) edges activate the trigger, falling edges deactivate it
+		event, transition := triggerevent.Activated, gpio.RiseEdge
+		if lr == gpio.Low {
+			event, transition = triggerevent.Deactivated, gpio.FallEdge
+		}
+
+		//	Only fire on the edge(s) the trigger is set up for
+		if edge&transition == 0 {
+			if lr == gpio.Low {
+				log.Debug().Int("GPIOPin", req.GPIOPin).Str("TriggerID", req.ID).Msg("Motion reset")
+			}
+			return
+		}
+
+		fire(event)
+	}
+
+	//	Debounce: a level only counts once the pin has stayed at it for the debounce window.
+	//	The timer restarts on every change, so contact chatter never gets through
+	debounce := time.Duration(req.DebounceMillis) * time.Millisecond
+	stable := time.NewTimer(debounce)
+	stopTimer(stable)
+	defer stable.Stop()
+	var pending gpio.Level
+
+	//	Watch the pin for changes (the current level is sent first)
+	sub := pins.subscribe(req.GPIOPin, time.Duration(req.SampleIntervalMillis)*time.Millisecond)
+	defer pins.unsubscribe(sub)
+
+	log.Debug().Int("GPIOPin", req.GPIOPin).Str("TriggerID", req.ID).Msg("Monitoring started")
+
+	//	Our channel checker and sensor reader
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case level := <-sub.changes:
+			if debounce <= 0 {
+				levelChanged(level)
+				continue
+			}
+
+			pending = level
+			stopTimer(stable)
+			stable.Reset(debounce)
+		case <-stable.C:
+			levelChanged(pending)
+		case now := <-gestureTimer.C:
+			fireGestures(gestures.tick(now))
+		}
+	}
+}
+
+// stopTimer stops the timer and clears it if it already fired, so it's safe to reset
+func stopTimer(t *time.Timer) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+}
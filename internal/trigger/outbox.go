@@ -0,0 +1,184 @@
+package trigger
+
+import (
+	"context"
+
+	"github.com/danesparza/fxtrigger/internal/data"
+	"github.com/danesparza/fxtrigger/internal/triggerevent"
+	"github.com/danesparza/fxtrigger/internal/triggersource"
+	"github.com/rs/zerolog/log"
+)
+
+// addToOutbox saves the pending webhook deliveries for a fire request to the outbox.
+// If they can't be saved, they're returned anyway (so they're still sent) -- they just won't survive a restart
+func (bp BackgroundProcess) addToOutbox(req FireRequest) []data.OutboxItem {
+
+	//	Render the webhook templates for this firing
+	event := NewEventContext(req)
+
+	items := []data.OutboxItem{}
+	for _, hook := range req.Trigger.WebHooksFor(req.Event) {
+		rendered, err := renderWebHook(hook, event)
+		if err != nil {
+			log.Err(err).Str("TriggerID", req.Trigger.ID).Str("HookUrl", hook.URL).Msg("Problem rendering webhook templates.  Sending it unrendered")
+		}
+
+		items = append(items, data.OutboxItem{
+			TriggerID:   req.Trigger.ID,
+			TriggerName: req.Trigger.Name,
+			Source:      req.Source,
+			Event:       req.Event,
+			Timestamp:   req.Time,
+			WebHook:     rendered,
+			HookURL:     hook.URL,
+		})
+	}
+
+	//	Nothing to deliver?  Just record the firing
+	if len(items) == 0 {
+		bp.recordHistory(data.HistoryItem{
+			TriggerID:   req.Trigger.ID,
+			TriggerName: req.Trigger.Name,
+			Source:      req.Source,
+			Event:       req.Event,
+			Timestamp:   req.Time,
+			WebHooks:    []data.DeliveryResult{},
+		})
+		bp.FireQueue.forget(req)
+		return items
+	}
+
+	//	Saved fires are moved to the outbox (so they're only replayed once)
+	var saved []data.OutboxItem
+	var err error
+	if req.queuedFireID != "" {
+		saved, err = bp.DB.MoveQueuedFireToOutbox(req.queuedFireID, items)
+	} else {
+		saved, err = bp.DB.AddOutboxItems(items)
+	}
+	if err != nil {
+		log.Err(err).Str("TriggerID", req.Trigger.ID).Msg("Problem saving deliveries to the outbox.  Sending anyway")
+		return items
+	}
+
+	return saved
+}
+
+// deliver sends the webhooks for a single trigger firing, removes each from the
+// outbox once it's finished, and records the history of the firing.
+// Deliveries that are interrupted because the service is stopping are left in the outbox
+func (bp BackgroundProcess) deliver(ctx context.Context, triggerID string, items []data.OutboxItem) {
+
+	//	Track the outcome of each webhook
+	results := []data.DeliveryResult{}
+
+	//	Loop through the pending deliveries
+	for _, item := range items {
+		//	Fire each of them...
+		result := bp.deliverWebHook(ctx, triggerID, metricsURL(item), item.WebHook)
+
+		//	If we're stopping, leave the rest in the outbox to replay at startup
+		if ctx.Err() != nil {
+			log.Info().Str("TriggerID", triggerID).Msg("Stopping before deliveries finished.  They will be replayed at startup")
+			return
+		}
+
+		results = append(results, result)
+
+		//	The delivery is finished (even if it failed after all retries), so remove it from the outbox
+		if item.ID != "" {
+			if err := bp.DB.DeleteOutboxItem(item.ID); err != nil {
+				log.Err(err).Str("TriggerID", triggerID).Str("OutboxID", item.ID).Msg("Problem removing delivery from the outbox")
+			}
+		}
+	}
+
+	if len(items) > 0 {
+		bp.recordHistory(data.HistoryItem{
+			TriggerID:   items[0].TriggerID,
+			TriggerName: items[0].TriggerName,
+			Source:      items[0].Source,
+			Event:       items[0].Event,
+			Timestamp:   items[0].Timestamp,
+			WebHooks:    results,
+		})
+
+		//	Let System triggers know about webhooks that kept failing.  Failures sending
+		//	system events aren't reported (so a broken webhook can't report itself forever)
+		if detail := deliveryFailureDetail(items[0].TriggerName, results); detail != "" && items[0].Source != triggersource.System {
+			bp.FireSystemEvent(triggerevent.DeliveryFailure, detail)
+		}
+	}
+}
+
+// metricsURL returns the URL to track delivery metrics by: the webhook URL before its templates were
+// rendered, so values that change with each fire (like {{.FireCount}}) don't track each fire separately
+func metricsURL(item data.OutboxItem) string {
+	if item.HookURL != "" {
+		return item.HookURL
+	}
+	return item.WebHook.URL
+}
+
+// recordHistory records the history of a trigger firing
+func (bp BackgroundProcess) recordHistory(item data.HistoryItem) {
+	if _, err := bp.DB.AddHistory(item, bp.HistoryTTL); err != nil {
+		log.Err(err).Str("TriggerID", item.TriggerID).Msg("Problem recording trigger history")
+	}
+}
+
+// ReplayOutbox adds any deliveries left in the outbox, and any fires that were still waiting to be
+// processed (from a previous run that stopped before they finished), to the fire queue.  It should be called once at startup
+func (bp BackgroundProcess) ReplayOutbox(systemctx context.Context) {
+
+	//	Get everything left in the outbox
+	items, err := bp.DB.GetAllOutboxItems()
+	if err != nil {
+		log.Err(err).Msg("Problem getting pending deliveries from the outbox")
+	}
+
+	//	Group the deliveries by the trigger firing they belong to (keeping them in order)
+	fires := []FireRequest{}
+	fireIndex := make(map[string]int)
+	for _, item := range items {
+		i, exists := fireIndex[item.FireID]
+		if !exists {
+			i = len(fires)
+			fireIndex[item.FireID] = i
+			fires = append(fires, FireRequest{
+				Trigger: data.Trigger{ID: item.TriggerID, Name: item.TriggerName},
+				Source:  item.Source,
+				Event:   item.Event,
+				Time:    item.Timestamp,
+			})
+		}
+		fires[i].deliveries = append(fires[i].deliveries, item)
+	}
+
+	//	Then get the fires that hadn't been processed yet
+	queuedFires, err := bp.DB.GetAllQueuedFires()
+	if err != nil {
+		log.Err(err).Msg("Problem getting saved trigger fires")
+	}
+
+	for _, fire := range queuedFires {
+		fires = append(fires, FireRequest{
+			Trigger:      fire.Trigger,
+			Source:       fire.Source,
+			Event:        fire.Event,
+			Time:         fire.Timestamp,
+			Level:        fire.Level,
+			Detail:       fire.Detail,
+			queuedFireID: fire.ID,
+		})
+	}
+
+	if len(fires) == 0 {
+		return
+	}
+
+	log.Info().Int("DeliveryCount", len(items)).Int("FireCount", len(queuedFires)).Msg("Replaying pending deliveries and trigger fires from the last run")
+
+	//	Send each one through the workers
+	bp.FireQueue.replay(systemctx, fires)
+}
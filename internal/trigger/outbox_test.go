@@ -0,0 +1,116 @@
+package trigger_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/danesparza/fxtrigger/internal/data"
+	"github.com/danesparza/fxtrigger/internal/trigger"
+	"github.com/danesparza/fxtrigger/internal/triggersource"
+)
+
+func TestOutbox_ReplayOutbox_PendingDeliveries_Delivered(t *testing.T) {
+
+	//	Arrange
+	bp := newTestProcess(t)
+
+	hooks := make(chan string, 10)
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		hooks <- req.URL.Path
+	}))
+	defer ts.Close()
+
+	//	Deliveries left over from a previous run
+	bp.DB.AddOutboxItems([]data.OutboxItem{
+		{TriggerID: "replay1", Source: triggersource.GPIO, Timestamp: time.Now(), WebHook: data.WebHook{URL: ts.URL + "/first"}},
+		{TriggerID: "replay1", Source: triggersource.GPIO, Timestamp: time.Now(), WebHook: data.WebHook{URL: ts.URL + "/second"}},
+	})
+
+	//	Act
+	bp.ReplayOutbox(context.Background())
+	gotItem := waitForHistory(t, bp, "replay1")
+	remaining, _ := bp.DB.GetAllOutboxItems()
+
+	//	Assert
+	if len(hooks) != 2 {
+		t.Errorf("ReplayOutbox failed: Should have sent both deliveries but got: %v", len(hooks))
+	}
+
+	if len(gotItem.WebHooks) != 2 {
+		t.Errorf("ReplayOutbox failed: Should have recorded a single history item for the firing but got: %+v", gotItem)
+	}
+
+	if len(remaining) != 0 {
+		t.Errorf("ReplayOutbox failed: Should have removed finished deliveries from the outbox but got: %v", len(remaining))
+	}
+}
+
+func TestOutbox_FireTrigger_DeliveredRemovedFromOutbox(t *testing.T) {
+
+	//	Arrange
+	bp := newTestProcess(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}))
+	defer ts.Close()
+
+	testTrigger := data.Trigger{ID: "outbox1", WebHooks: []data.WebHook{{URL: ts.URL}}}
+
+	//	Act
+	bp.FireQueue.Enqueue(trigger.FireRequest{Trigger: testTrigger, Source: triggersource.API, Time: time.Now()})
+	waitForHistory(t, bp, testTrigger.ID)
+	remaining, _ := bp.DB.GetAllOutboxItems()
+
+	//	Assert
+	if len(remaining) != 0 {
+		t.Errorf("FireTrigger failed: Should have removed the delivery from the outbox but got: %v", len(remaining))
+	}
+}
+
+func TestOutbox_ReplayOutbox_ManyFires_UsesWorkersAndDrains(t *testing.T) {
+
+	//	Arrange
+	bp := newTestProcess(t)
+
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		<-release
+	}))
+	defer ts.Close()
+
+	//	Ten firings left over from a previous run
+	for i := 0; i < 10; i++ {
+		bp.DB.AddOutboxItems([]data.OutboxItem{
+			{TriggerID: "replaymany", Source: triggersource.GPIO, Timestamp: time.Now(), WebHook: data.WebHook{URL: ts.URL}},
+		})
+	}
+
+	//	Act
+	bp.ReplayOutbox(context.Background())
+	for i := 0; i < 100 && bp.FireQueue.Status().ActiveWorkers < 4; i++ {
+		time.Sleep(20 * time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+	status := bp.FireQueue.Status()
+
+	close(release)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	drainErr := bp.FireQueue.Drain(ctx)
+	remaining, _ := bp.DB.GetAllOutboxItems()
+
+	//	Assert
+	if status.ActiveWorkers != 4 || status.Depth != 6 {
+		t.Errorf("ReplayOutbox failed: Should have delivered through the (4) workers and queued the rest but got: %+v", status)
+	}
+
+	if drainErr != nil {
+		t.Errorf("Drain failed: Should have waited for the replayed deliveries but got: %v", drainErr)
+	}
+
+	if len(remaining) != 0 {
+		t.Errorf("ReplayOutbox failed: Should have removed finished deliveries from the outbox but got: %v", len(remaining))
+	}
+}
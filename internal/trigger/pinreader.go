@@ -0,0 +1,162 @@
+package trigger
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/danesparza/fxtrigger/internal/gpio"
+	"github.com/rs/zerolog/log"
+)
+
+// edgeCheckInterval is how often the pin reader checks for detected edges.  The driver
+// latches edges between checks, so pulses shorter than this aren't missed
+const edgeCheckInterval = 10 * time.Millisecond
+
+// pinSubscription receives the (raw) level changes for a monitored pin
+type pinSubscription struct {
+	pin            int
+	changes        chan gpio.Level
+	sampleInterval time.Duration // If set, the pin is sampled at this interval instead of using edge detection
+	level          gpio.Level    // The last level sent
+	lastSample     time.Time
+}
+
+// pinReader is the single shared reader for all monitored pins
+type pinReader struct {
+	driver gpio.Driver
+	subs   map[int][]*pinSubscription
+	mutex  sync.Mutex
+}
+
+// newPinReader creates a pin reader for the (already opened) driver
+func newPinReader(driver gpio.Driver) *pinReader {
+	return &pinReader{
+		driver: driver,
+		subs:   make(map[int][]*pinSubscription),
+	}
+}
+
+// subscribe starts watching a pin.  The current level of the pin is sent right away.
+// If sampleInterval is zero, edge detection is used
+func (r *pinReader) subscribe(pin int, sampleInterval time.Duration) *pinSubscription {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	sub := &pinSubscription{
+		pin:            pin,
+		changes:        make(chan gpio.Level, 16),
+		sampleInterval: sampleInterval,
+		lastSample:     time.Now(),
+	}
+
+	//	The first subscriber that uses edge detection turns it on for the pin
+	if sampleInterval == 0 && !r.usesEdgeDetection(pin) {
+		r.driver.Detect(pin, gpio.AnyEdge)
+	}
+
+	r.subs[pin] = append(r.subs[pin], sub)
+
+	//	Send the starting level
+	sub.send(r.driver.Read(pin))
+
+	return sub
+}
+
+// unsubscribe stops watching a pin
+func (r *pinReader) unsubscribe(sub *pinSubscription) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	subs := r.subs[sub.pin]
+	for i, s := range subs {
+		if s == sub {
+			subs = append(subs[:i], subs[i+1:]...)
+			break
+		}
+	}
+
+	if len(subs) == 0 {
+		delete(r.subs, sub.pin)
+	} else {
+		r.subs[sub.pin] = subs
+	}
+
+	//	The last subscriber that uses edge detection turns it off for the pin
+	if sub.sampleInterval == 0 && !r.usesEdgeDetection(sub.pin) {
+		r.driver.Detect(sub.pin, gpio.NoEdge)
+	}
+}
+
+// run checks the subscribed pins until the context is cancelled
+func (r *pinReader) run(ctx context.Context) {
+	ticker := time.NewTicker(edgeCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			r.check(now)
+		}
+	}
+}
+
+// check sends any level changes on the subscribed pins
+func (r *pinReader) check(now time.Time) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for pin, subs := range r.subs {
+
+		//	Edge detection
+		if r.usesEdgeDetection(pin) && r.driver.EdgeDetected(pin) {
+			level := r.driver.Read(pin)
+			for _, sub := range subs {
+				if sub.sampleInterval > 0 {
+					continue
+				}
+
+				//	If the level hasn't changed, the pin pulsed between checks.  Send both edges
+				if level == sub.level {
+					sub.send(level ^ gpio.High)
+				}
+				sub.send(level)
+			}
+		}
+
+		//	Sampling (fallback)
+		for _, sub := range subs {
+			if sub.sampleInterval == 0 || now.Sub(sub.lastSample) < sub.sampleInterval {
+				continue
+			}
+			sub.lastSample = now
+
+			if level := r.driver.Read(pin); level != sub.level {
+				sub.send(level)
+			}
+		}
+	}
+}
+
+// usesEdgeDetection returns true if any subscriber for the pin uses edge detection.  Must be called with the lock held
+func (r *pinReader) usesEdgeDetection(pin int) bool {
+	for _, sub := range r.subs[pin] {
+		if sub.sampleInterval == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// send sends a level change to the subscriber without blocking the reader
+func (sub *pinSubscription) send(level gpio.Level) {
+	sub.level = level
+
+	select {
+	case sub.changes <- level:
+	default:
+		log.Warn().Int("GPIOPin", sub.pin).Msg("Monitor isn't keeping up with pin changes.  Dropping a change")
+	}
+}
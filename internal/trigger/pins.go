@@ -0,0 +1,184 @@
+package trigger
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/danesparza/fxtrigger/internal/data"
+	"github.com/danesparza/fxtrigger/internal/triggertype"
+)
+
+// ReservedPins are the GPIO (BCM) pins used by the Raspberry Pi I2C, SPI and UART interfaces
+// and the I2C bus that reads the HAT EEPROM at boot
+var ReservedPins = map[int]string{
+	0:  "HAT EEPROM I2C (ID_SD)",
+	1:  "HAT EEPROM I2C (ID_SC)",
+	2:  "I2C SDA",
+	3:  "I2C SCL",
+	7:  "SPI CE1",
+	8:  "SPI CE0",
+	9:  "SPI MISO",
+	10: "SPI MOSI",
+	11: "SPI SCLK",
+	14: "UART TX",
+	15: "UART RX",
+}
+
+// PinConflictError is a trigger that can't use its GPIO pin
+type PinConflictError struct {
+	Pin    int
+	Reason string
+}
+
+// Error describes the conflict
+func (e PinConflictError) Error() string {
+	return fmt.Sprintf("gpio pin %v %v", e.Pin, e.Reason)
+}
+
+// pinClaim is a running trigger's claim on a GPIO pin
+type pinClaim struct {
+	triggerID   string
+	triggerName string
+	pull        string
+}
+
+// PinRegistry tracks which running triggers are using each GPIO pin.  Triggers can share a pin
+// (they share one pin reader) as long as their pin settings are compatible.  A nil registry allows every claim
+type PinRegistry struct {
+	allowReserved bool
+	claims        map[int][]*pinClaim
+	mutex         sync.Mutex
+}
+
+// NewPinRegistry creates a pin registry.  If allowReserved is true, triggers
+// can use the pins reserved for I2C, SPI, UART and the HAT EEPROM
+func NewPinRegistry(allowReserved bool) *PinRegistry {
+	return &PinRegistry{
+		allowReserved: allowReserved,
+		claims:        make(map[int][]*pinClaim),
+	}
+}
+
+// Check makes sure the trigger can use its GPIO pin, alongside the triggers that are already
+// using it and the other enabled triggers (that will be using it).  If it can't, a PinConflictError is returned
+func (r *PinRegistry) Check(t data.Trigger, others []data.Trigger) error {
+	if r == nil || !usesPin(t) {
+		return nil
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.check(t); err != nil {
+		return err
+	}
+
+	for _, other := range others {
+		if other.Enabled && usesPin(other) && other.GPIOPin == t.GPIOPin {
+			if err := conflict(t, pinClaim{triggerID: other.ID, triggerName: other.Name, pull: other.Pull}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Claim records that a trigger is using its GPIO pin.  Call the returned function when it stops using it.
+// If the pin can't be used, a PinConflictError is returned
+func (r *PinRegistry) Claim(t data.Trigger) (release func(), err error) {
+	if r == nil || !usesPin(t) {
+		return func() {}, nil
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.check(t); err != nil {
+		return nil, err
+	}
+
+	claim := &pinClaim{triggerID: t.ID, triggerName: t.Name, pull: t.Pull}
+	r.claims[t.GPIOPin] = append(r.claims[t.GPIOPin], claim)
+
+	return func() { r.release(t.GPIOPin, claim) }, nil
+}
+
+// check makes sure the trigger can use its pin.  Must be called with the lock held
+func (r *PinRegistry) check(t data.Trigger) error {
+	if reserved, exists := ReservedPins[t.GPIOPin]; exists && !r.allowReserved {
+		return PinConflictError{Pin: t.GPIOPin, Reason: fmt.Sprintf("is reserved for %v", reserved)}
+	}
+
+	for _, claim := range r.claims[t.GPIOPin] {
+		if err := conflict(t, *claim); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// conflict returns a PinConflictError if the trigger can't share its pin with the claim
+func conflict(t data.Trigger, claim pinClaim) error {
+	if claim.triggerID == t.ID {
+		return nil
+	}
+
+	//	The pull resistor is a setting of the pin itself, so triggers sharing a pin need to agree on it
+	if t.Pull != "" && claim.pull != "" && !strings.EqualFold(t.Pull, claim.pull) {
+		return PinConflictError{
+			Pin:    t.GPIOPin,
+			Reason: fmt.Sprintf("is already used by trigger '%v' (%v) with pull %v", claim.triggerName, claim.triggerID, claim.pull),
+		}
+	}
+
+	return nil
+}
+
+// release removes a claim
+func (r *PinRegistry) release(pin int, claim *pinClaim) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	claims := r.claims[pin]
+	for i, c := range claims {
+		if c == claim {
+			claims = append(claims[:i], claims[i+1:]...)
+			break
+		}
+	}
+
+	if len(claims) == 0 {
+		delete(r.claims, pin)
+	} else {
+		r.claims[pin] = claims
+	}
+}
+
+// Claims returns the ids of the triggers using each pin
+func (r *PinRegistry) Claims() map[int][]string {
+	retval := make(map[int][]string)
+	if r == nil {
+		return retval
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for pin, claims := range r.claims {
+		for _, claim := range claims {
+			retval[pin] = append(retval[pin], claim.triggerID)
+		}
+		sort.Strings(retval[pin])
+	}
+
+	return retval
+}
+
+// usesPin returns true if the type of trigger watches a GPIO pin
+func usesPin(t data.Trigger) bool {
+	return t.Type != triggertype.Time && t.Type != triggertype.System
+}
@@ -0,0 +1,115 @@
+package trigger_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/danesparza/fxtrigger/internal/data"
+	"github.com/danesparza/fxtrigger/internal/trigger"
+	"github.com/danesparza/fxtrigger/internal/triggertype"
+)
+
+func TestPins_Claim_CompatibleTriggers_SharePin(t *testing.T) {
+
+	//	Arrange
+	pins := trigger.NewPinRegistry(false)
+	motion := data.Trigger{ID: "motion", Type: triggertype.Motion, GPIOPin: 17, Pull: "up"}
+	button := data.Trigger{ID: "button", Type: triggertype.Button, GPIOPin: 17, Pull: "up", ActiveLow: true}
+
+	//	Act
+	releaseMotion, err1 := pins.Claim(motion)
+	releaseButton, err2 := pins.Claim(button)
+
+	//	Assert
+	if err1 != nil || err2 != nil {
+		t.Fatalf("Claim failed: Compatible triggers should share the pin but got: %v / %v", err1, err2)
+	}
+
+	if claims := pins.Claims()[17]; len(claims) != 2 {
+		t.Errorf("Claim failed: Should have two claims on the pin but got: %v", claims)
+	}
+
+	releaseMotion()
+	releaseButton()
+	if claims := pins.Claims(); len(claims) != 0 {
+		t.Errorf("Claim failed: Releasing should remove the claims but got: %v", claims)
+	}
+}
+
+func TestPins_Claim_DifferentPull_Conflict(t *testing.T) {
+
+	//	Arrange
+	pins := trigger.NewPinRegistry(false)
+	pins.Claim(data.Trigger{ID: "first", Name: "First", GPIOPin: 17, Pull: "up"})
+
+	//	Act
+	_, err := pins.Claim(data.Trigger{ID: "second", Name: "Second", GPIOPin: 17, Pull: "down"})
+
+	//	Assert
+	var conflict trigger.PinConflictError
+	if !errors.As(err, &conflict) || conflict.Pin != 17 {
+		t.Errorf("Claim failed: Should have returned a pin conflict but got: %v", err)
+	}
+}
+
+func TestPins_Check_ReservedPin_Conflict(t *testing.T) {
+
+	//	Arrange
+	pins := trigger.NewPinRegistry(false)
+	allowed := trigger.NewPinRegistry(true)
+	uart := data.Trigger{ID: "uart", GPIOPin: 14}
+
+	//	Act
+	err := pins.Check(uart, nil)
+	allowedErr := allowed.Check(uart, nil)
+
+	//	Assert
+	if err == nil {
+		t.Errorf("Check failed: Should not allow a reserved pin")
+	}
+
+	if allowedErr != nil {
+		t.Errorf("Check failed: Should allow a reserved pin when reserved pins are allowed but got: %v", allowedErr)
+	}
+}
+
+func TestPins_Check_EnabledTriggers_Conflict(t *testing.T) {
+
+	//	Arrange
+	pins := trigger.NewPinRegistry(false)
+	others := []data.Trigger{
+		{ID: "disabled", GPIOPin: 17, Pull: "down", Enabled: false},
+		{ID: "time", Type: triggertype.Time, Enabled: true},
+		{ID: "enabled", GPIOPin: 18, Pull: "down", Enabled: true},
+	}
+
+	//	Act
+	err17 := pins.Check(data.Trigger{ID: "new", GPIOPin: 17, Pull: "up"}, others)
+	err18 := pins.Check(data.Trigger{ID: "new", GPIOPin: 18, Pull: "up"}, others)
+
+	//	Assert
+	if err17 != nil {
+		t.Errorf("Check failed: Disabled triggers shouldn't conflict but got: %v", err17)
+	}
+
+	if err18 == nil {
+		t.Errorf("Check failed: Should conflict with an enabled trigger on the pin")
+	}
+}
+
+func TestPins_Check_HATEEPROMPins_Conflict(t *testing.T) {
+
+	//	Arrange
+	pins := trigger.NewPinRegistry(false)
+
+	for _, pin := range []int{0, 1} {
+		//	Act
+		err := pins.Check(data.Trigger{ID: "eeprom", GPIOPin: pin}, nil)
+
+		//	Assert
+		var conflict trigger.PinConflictError
+		if !errors.As(err, &conflict) || conflict.Pin != pin {
+			t.Errorf("Check failed: Should not allow HAT EEPROM pin %v but got: %v", pin, err)
+		}
+	}
+}
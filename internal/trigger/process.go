@@ -5,13 +5,15 @@ import (
 	"context"
 	"fmt"
 	"github.com/danesparza/fxtrigger/internal/data"
+	"github.com/danesparza/fxtrigger/internal/gpio"
+	"github.com/danesparza/fxtrigger/signature"
 	"github.com/rs/zerolog/log"
+	"io"
 	"net/http"
-	"os"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
-
-	"github.com/danesparza/go-rpio"
 )
 
 // BackgroundProcess encapsulates background processing operations
@@ -19,62 +21,142 @@ type BackgroundProcess struct {
 	DB         *data.Manager
 	HistoryTTL time.Duration
 
-	// FireTrigger signals a trigger should be fired
-	FireTrigger chan data.Trigger
+	// GPIO is the (already opened) driver used to read monitored pins
+	GPIO gpio.Driver
+
+	// Metrics tracks webhook delivery statistics
+	Metrics *DeliveryMetrics
+
+	// Pins tracks which triggers are using each GPIO pin
+	Pins *PinRegistry
+
+	// FireQueue holds the trigger fires waiting to be processed
+	FireQueue *FireQueue
+
+	// Reconcile signals the monitors should be brought in line with the stored triggers
+	Reconcile chan ReconcileRequest
 
-	// AddMonitor signals a trigger should be added to the list of monitored triggers
-	AddMonitor chan data.Trigger
+	// WebHookSecret signs webhooks that don't have their own secret.  If it's empty, they aren't signed
+	WebHookSecret string
+}
 
-	// RemoveMonitor signals a trigger id should not be monitored anymore
-	RemoveMonitor chan string
+// responseSnippetBytes is the maximum number of response body bytes kept with a delivery result
+const responseSnippetBytes = 512
+
+// FireRequest is a request to fire a trigger
+type FireRequest struct {
+	Trigger   data.Trigger // The trigger to fire
+	Source    string       // What caused the trigger to fire (see triggersource)
+	Event     string       // The trigger event (see triggerevent)
+	Time      time.Time    // When the trigger fired
+	Level     string       // The pin level when the trigger fired (high or low).  Empty if it wasn't fired by a pin
+	Detail    string       // Details about a system event (like the monitor or webhook that failed).  Empty for other events
+	FireCount int          // The number of times the trigger has fired since the service started.  Set by the processor
+
+	queuedFireID string            // The id of the saved fire (until it's processed).  Empty if it wasn't saved
+	deliveries   []data.OutboxItem // Deliveries replayed from the outbox (instead of new ones)
 }
 
+// monitoredTriggersMap tracks the running monitors.  It's only used by the ListenForEvents goroutine
 type monitoredTriggersMap struct {
-	m       map[string]func()
-	rwMutex sync.RWMutex
+	m map[string]*monitor
+}
+
+// monitor is a running trigger monitor
+type monitor struct {
+	trigger data.Trigger  // The trigger settings the monitor was started with
+	cancel  func()        // Stops the monitor
+	done    chan struct{} // Closed once the monitor has stopped
 }
 
-// HandleAndProcess handles system context calls and channel events to fire triggers
+// HandleAndProcess handles system context calls and channel events to fire triggers.
+// When the context is cancelled, it returns once the workers delivering webhooks have stopped
 func (bp BackgroundProcess) HandleAndProcess(systemctx context.Context) {
 
+	//	Count how many times each trigger has fired
+	fireCounts := make(map[string]int)
+
+	//	Fires held during do not disturb (in queue mode).  They're still saved until they're sent
+	queued := []FireRequest{}
+	dndCheck := time.NewTicker(dndCheckInterval)
+	defer dndCheck.Stop()
+
+	//	Start the workers that deliver the webhooks.  Once we're stopping, wait for them to finish
+	work := make(chan FireRequest)
+	var workers sync.WaitGroup
+	for i := 0; i < bp.FireQueue.workers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			bp.deliverFires(systemctx, work)
+		}()
+	}
+	defer workers.Wait()
+
+	//	send hands a fire request to the next available worker
+	send := func(req FireRequest) {
+		select {
+		case work <- req:
+		case <-systemctx.Done():
+		}
+	}
+
+	//	process counts a fire request and checks do not disturb before it's sent
+	process := func(fireReq FireRequest) {
+		fireCounts[fireReq.Trigger.ID]++
+		fireReq.FireCount = fireCounts[fireReq.Trigger.ID]
+
+		//	Check do not disturb before we send anything
+		switch bp.dndMode(fireReq) {
+		case DNDSuppress:
+			log.Debug().Str("TriggerID", fireReq.Trigger.ID).Str("Event", fireReq.Event).Msg("Do not disturb is active.  Suppressing trigger")
+			bp.recordHistory(data.HistoryItem{
+				TriggerID:   fireReq.Trigger.ID,
+				TriggerName: fireReq.Trigger.Name,
+				Source:      fireReq.Source,
+				Event:       fireReq.Event,
+				Timestamp:   fireReq.Time,
+				WebHooks:    []data.DeliveryResult{},
+				Suppressed:  true,
+			})
+			bp.FireQueue.forget(fireReq)
+			bp.FireQueue.track(-1)
+		case DNDQueue:
+			log.Debug().Str("TriggerID", fireReq.Trigger.ID).Str("Event", fireReq.Event).Msg("Do not disturb is active.  Queueing trigger")
+			//	The fire stays saved while it's held, so it's held again (or sent) after a restart
+			var replaced *FireRequest
+			queued, replaced = queueFire(queued, fireReq)
+			if replaced != nil {
+				bp.FireQueue.forget(*replaced)
+			}
+			bp.FireQueue.track(-1)
+		default:
+			send(fireReq)
+		}
+	}
+
 	//	Loop and respond to channels:
 	for {
 		select {
-		case trigReq := <-bp.FireTrigger:
-			//	As we get a request on a channel to fire a trigger...
-			//	Create a goroutine
-			go func(cx context.Context, trigger data.Trigger) {
-
-				//	Loop through the associated webhooks
-				for _, hook := range trigger.WebHooks {
-					//	Fire each of them...
-
-					//	First, build the initial request with the verb, url and body (if the body exists)
-					req, err := http.NewRequestWithContext(systemctx, http.MethodPost, hook.URL, bytes.NewBuffer(hook.Body))
-					if err != nil {
-						log.Err(err).Str("TriggerID", trigger.ID).Str("HookUrl", hook.URL).Msg("Error creating request for trigger/hook")
-						continue //	Go to the next hook
-					}
-
-					//	Then, set our initial content-type header
-					req.Header.Set("Content-Type", "application/json")
-
-					//	Next, set any custom headers
-					for k, v := range hook.Headers {
-						req.Header.Set(k, v)
-					}
-
-					//	Finally, send the request
-					client := &http.Client{Timeout: time.Second * 10}
-					resp, err := client.Do(req)
-					if err != nil {
-						log.Err(err).Str("TriggerID", trigger.ID).Str("HookUrl", hook.URL).Msg("Error with response for trigger/hook")
-						//	'continue' doesn't really matter here -- we're already at the end of this loop
-					}
-					defer resp.Body.Close()
+		case fireReq := <-bp.FireQueue.requests:
+			process(fireReq)
+		case replayReq := <-bp.FireQueue.replays:
+			//	Replayed deliveries already made it past do not disturb.  Replayed fires haven't
+			if replayReq.deliveries != nil {
+				send(replayReq)
+			} else {
+				process(replayReq)
+			}
+		case <-dndCheck.C:
+			//	Once do not disturb ends, send anything that was queued
+			if len(queued) > 0 && !bp.dndActive() {
+				log.Info().Int("FireCount", len(queued)).Msg("Do not disturb ended.  Sending queued triggers")
+				for _, req := range queued {
+					bp.FireQueue.track(1)
+					send(req)
 				}
-
-			}(systemctx, trigReq) // Launch the goroutine
+				queued = []FireRequest{}
+			}
 		case <-systemctx.Done():
 			fmt.Println("Stopping trigger processor")
 			return
@@ -82,117 +164,135 @@ func (bp BackgroundProcess) HandleAndProcess(systemctx context.Context) {
 	}
 }
 
-// ListenForEvents listens to channel events to add / remove monitors
-//
-//	and 'fires' triggers when an event (motion / button press / time) occurs from a monitor
-func (bp BackgroundProcess) ListenForEvents(systemctx context.Context) {
-
-	//	Track our list of active event monitors.  These could be buttons or sensors
-	monitoredTriggers := monitoredTriggersMap{m: make(map[string]func())}
-
-	//	Loop and respond to channels:
+// deliverFires delivers the webhooks for each fire request it's handed, until the context is cancelled
+func (bp BackgroundProcess) deliverFires(systemctx context.Context, work <-chan FireRequest) {
 	for {
 		select {
-		case monitorReq := <-bp.AddMonitor:
-			//	This should be called when creating a trigger,
-			//	when initializing the service,
-			//	or when enabling a trigger (that was previously disabled)
-
-			//	If you need to add a monitor, spin up a background goroutine to monitor that pin
-			go func(cx context.Context, req data.Trigger) {
-
-				//	Create a cancelable context from the passed (system) context
-				ctx, cancel := context.WithCancel(cx)
-				defer cancel()
-
-				//	Add an entry to the map with
-				//	- key: triggerid
-				//	- value: the cancel function (pointer)
-				//	(critical section)
-				monitoredTriggers.rwMutex.Lock()
-				monitoredTriggers.m[req.ID] = cancel
-				monitoredTriggers.rwMutex.Unlock()
-
-				if err := rpio.Open(); err != nil {
-					fmt.Println(err)
-					os.Exit(1)
-				}
-				defer rpio.Close()
-
-				pin := rpio.Pin(req.GPIOPin)
-				pin.Mode(rpio.Input)
-
-				//	Store the 'last reading'
-				//	Initially, set it to the 'low' (no motion) state
-				lr := rpio.Low
-				lastTrigger := time.Unix(0, 0) // Initialize with 1/1/1970
-
-				log.Debug().Int("GPIOPin", req.GPIOPin).Str("TriggerID", req.ID).Msg("Monitoring started")
-
-				//	Our channel checker and sensor reader
-				for {
-					select {
-					case <-ctx.Done():
-						//	Remove ourselves from the map and exit (critical section)
-						monitoredTriggers.rwMutex.Lock()
-						delete(monitoredTriggers.m, req.ID)
-						monitoredTriggers.rwMutex.Unlock()
-						return
-					case <-time.After(500 * time.Millisecond):
-						//	Read from the sensor
-						v := pin.Read()
-
-						//	Latch / unlatch check
-						if lr != v {
-							lr = v
-							currentTime := time.Now()
-							diff := currentTime.Sub(lastTrigger)
-
-							if lr == rpio.High {
-								if diff.Seconds() > float64(req.MinimumSecondsBeforeRetrigger) {
-									//	If it's been long enough -- reset the lrTime to now
-									//	and actually trigger the item
-									lastTrigger = currentTime
-									log.Debug().Int("GPIOPin", req.GPIOPin).Str("TriggerID", req.ID).Msg("Motion detected.  Firing event")
-									bp.FireTrigger <- req
-								} else {
-									log.Debug().
-										Int("GPIOPin", req.GPIOPin).
-										Str("TriggerID", req.ID).
-										Int("MinimumSecondsBeforeRetrigger", req.MinimumSecondsBeforeRetrigger).
-										Msg("Motion detected, but minimum seconds threshold not met.  Not triggering.")
-								}
-							}
-							if lr == rpio.Low {
-								log.Debug().Int("GPIOPin", req.GPIOPin).Str("TriggerID", req.ID).Msg("Motion reset")
-							}
-						}
-					}
-				}
+		case req := <-work:
+			bp.FireQueue.started()
 
-			}(systemctx, monitorReq) // Launch the goroutine
+			//	Save the pending deliveries to the outbox (unless they're being replayed from it), then send them
+			deliveries := req.deliveries
+			if deliveries == nil {
+				deliveries = bp.addToOutbox(req)
+			}
+			bp.deliver(systemctx, req.Trigger.ID, deliveries)
 
-		case removeReq := <-bp.RemoveMonitor:
-			//	This should be called when removing a trigger (permanently)
-			//	or when disabling a trigger
+			bp.FireQueue.finished()
+		case <-systemctx.Done():
+			return
+		}
+	}
+}
 
-			//	Look up the item in the map and call cancel if the item exists (critical section):
-			monitoredTriggers.rwMutex.Lock()
-			monitorCancel, exists := monitoredTriggers.m[removeReq]
+// sendWebHook makes a single attempt to send a webhook for a trigger and returns the outcome.
+// The attempt is tracked in the delivery metrics under the metrics URL
+func (bp BackgroundProcess) sendWebHook(ctx context.Context, triggerID, metricsURL string, hook data.WebHook) data.DeliveryResult {
 
-			if exists {
-				log.Debug().Str("TriggerID", removeReq).Msg("Monitoring stopped")
+	retval := data.DeliveryResult{URL: hook.URL}
 
-				//	Call the context cancellation function
-				monitorCancel()
+	//	Track the outcome once we're done
+	start := time.Now()
+	defer func() {
+		retval.DurationMillis = time.Since(start).Milliseconds()
 
-				//	Remove ourselves from the map and exit
-				delete(monitoredTriggers.m, removeReq)
+		metric := retval
+		metric.URL = metricsURL
+		bp.Metrics.Record(metric)
+	}()
+
+	//	First, build the initial request with the verb, url and body (if the body exists)
+	req, err := http.NewRequestWithContext(ctx, WebHookMethod(hook), hook.URL, bytes.NewBuffer(hook.Body))
+	if err != nil {
+		log.Err(err).Str("TriggerID", triggerID).Str("HookUrl", hook.URL).Msg("Error creating request for trigger/hook")
+		retval.Error = err.Error()
+		return retval
+	}
+
+	//	Then, set our initial content-type header
+	contentType := "application/json"
+	if strings.TrimSpace(hook.ContentType) != "" {
+		contentType = hook.ContentType
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	//	Next, set any custom headers
+	for k, v := range hook.Headers {
+		req.Header.Set(k, v)
+	}
+
+	//	Sign the request (if there's a secret).  Each attempt gets a fresh timestamp
+	if secret := bp.webHookSecret(hook); secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(signature.TimestampHeader, timestamp)
+		req.Header.Set(signature.SignatureHeader, signature.Sign(secret, timestamp, hook.Body))
+	}
+
+	//	Finally, send the request
+	client := &http.Client{Timeout: timeout(hook)}
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Err(err).Str("TriggerID", triggerID).Str("HookUrl", hook.URL).Msg("Error with response for trigger/hook")
+		retval.Error = err.Error()
+		return retval
+	}
+	defer resp.Body.Close()
+
+	//	Capture the status and the first part of the response
+	retval.StatusCode = resp.StatusCode
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, responseSnippetBytes))
+	retval.ResponseSnippet = string(snippet)
+
+	if !retval.Succeeded() {
+		log.Error().Str("TriggerID", triggerID).Str("HookUrl", hook.URL).Int("StatusCode", resp.StatusCode).Str("Response", retval.ResponseSnippet).Msg("Unsuccessful response for trigger/hook")
+	}
+
+	return retval
+}
+
+// webHookSecret returns the secret used to sign the webhook (or an empty string if it isn't signed)
+func (bp BackgroundProcess) webHookSecret(hook data.WebHook) string {
+	if hook.Secret != "" {
+		return hook.Secret
+	}
+	return bp.WebHookSecret
+}
+
+// WebHookMethod returns the HTTP verb to use for the webhook
+func WebHookMethod(hook data.WebHook) string {
+	if strings.TrimSpace(hook.Method) == "" {
+		return http.MethodPost
+	}
+	return strings.ToUpper(strings.TrimSpace(hook.Method))
+}
+
+// ListenForEvents listens to channel events to start / stop monitors
+//
+//	and 'fires' triggers when an event (motion / button press / time) occurs from a monitor.
+//	When the context is cancelled, it stops every monitor and returns once they've stopped
+func (bp BackgroundProcess) ListenForEvents(systemctx context.Context) {
+
+	//	Track our list of active event monitors.  These could be buttons or sensors
+	monitoredTriggers := &monitoredTriggersMap{m: make(map[string]*monitor)}
+
+	//	All monitors share a single pin reader
+	pins := newPinReader(bp.GPIO)
+	go pins.run(systemctx)
+
+	//	Loop and respond to channels:
+	for {
+		select {
+		case reconcileReq := <-bp.Reconcile:
+			//	This should be called when creating, changing, enabling, disabling or removing
+			//	a trigger, and when initializing the service
+			bp.reconcile(systemctx, pins, monitoredTriggers, reconcileReq.TriggerID)
+			if reconcileReq.Done != nil {
+				close(reconcileReq.Done)
 			}
-			monitoredTriggers.rwMutex.Unlock()
 
 		case <-systemctx.Done():
-			fmt.Println("Stopping trigger processor")
+			fmt.Println("Stopping trigger monitors")
+			monitoredTriggers.stopAll()
 			return
 		}
 	}
@@ -201,18 +301,10 @@ func (bp BackgroundProcess) ListenForEvents(systemctx context.Context) {
 // InitializeMonitors starts all monitoring processes
 func (bp BackgroundProcess) InitializeMonitors() {
 
-	//	Get all triggers:
-	allTriggers, err := bp.DB.GetAllTriggers()
-	if err != nil {
-		log.Err(err).Msg("Problem getting all triggers to initialze monitors")
-	}
-
-	log.Debug().Int("TriggerCount", len(allTriggers)).Msg("Initializing monitoring")
+	log.Debug().Msg("Initializing monitoring")
 
 	//	Start monitoring all enabled triggers:
-	for _, trigger := range allTriggers {
-		if trigger.Enabled {
-			bp.AddMonitor <- trigger
-		}
-	}
+	done := make(chan struct{})
+	bp.Reconcile <- ReconcileRequest{TriggerID: ReconcileAll, Done: done}
+	<-done
 }
@@ -0,0 +1,170 @@
+package trigger_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/danesparza/fxtrigger/internal/data"
+	"github.com/danesparza/fxtrigger/internal/trigger"
+	"github.com/danesparza/fxtrigger/internal/triggersource"
+	"github.com/danesparza/fxtrigger/signature"
+)
+
+func TestProcess_FireTrigger_UsesWebHookMethod(t *testing.T) {
+
+	//	Arrange
+	bp := newTestProcess(t)
+
+	methods := make(chan string, 10)
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		methods <- req.Method + " " + req.URL.RawQuery
+	}))
+	defer ts.Close()
+
+	testTrigger := data.Trigger{ID: "method1", WebHooks: []data.WebHook{
+		{URL: ts.URL + "?turn=on", Method: "get"},
+		{URL: ts.URL},
+	}}
+
+	//	Act
+	bp.FireQueue.Enqueue(trigger.FireRequest{Trigger: testTrigger, Source: triggersource.API, Time: time.Now()})
+	waitForHistory(t, bp, testTrigger.ID)
+
+	//	Assert
+	if got := <-methods; got != "GET turn=on" {
+		t.Errorf("FireTrigger failed: Should have sent a GET with the query string but got: %v", got)
+	}
+
+	if got := <-methods; got != "POST " {
+		t.Errorf("FireTrigger failed: Should have defaulted to POST but got: %v", got)
+	}
+}
+
+func TestProcess_FireTrigger_SignsWebHooks(t *testing.T) {
+
+	//	Arrange
+	db, err := data.NewManager(filepath.Join(t.TempDir(), "system.db"))
+	if err != nil {
+		t.Fatalf("NewManager failed: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		db.Close()
+	}()
+
+	bp := trigger.BackgroundProcess{
+		FireQueue:     trigger.NewFireQueue(trigger.FireQueueOptions{DB: db}),
+		DB:            db,
+		Metrics:       trigger.NewDeliveryMetrics(),
+		WebHookSecret: "global-secret",
+	}
+	go bp.HandleAndProcess(ctx)
+
+	verified := make(chan error, 10)
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		secret := "global-secret"
+		if req.URL.Query().Get("hook") == "own" {
+			secret = "hook-secret"
+		}
+		_, err := signature.VerifyRequest(req, secret, time.Minute)
+		verified <- err
+	}))
+	defer ts.Close()
+
+	testTrigger := data.Trigger{ID: "signed1", WebHooks: []data.WebHook{
+		{URL: ts.URL + "?hook=own", Secret: "hook-secret", Body: []byte(`{"light":"on"}`)},
+		{URL: ts.URL + "?hook=global"},
+	}}
+
+	//	Act
+	bp.FireQueue.Enqueue(trigger.FireRequest{Trigger: testTrigger, Source: triggersource.API, Time: time.Now()})
+	waitForHistory(t, bp, testTrigger.ID)
+
+	//	Assert
+	for i := 0; i < 2; i++ {
+		if err := <-verified; err != nil {
+			t.Errorf("FireTrigger failed: Should have sent a valid signature but got: %v", err)
+		}
+	}
+}
+
+func TestProcess_FireTrigger_NoSecret_NotSigned(t *testing.T) {
+
+	//	Arrange
+	bp := newTestProcess(t)
+
+	headers := make(chan http.Header, 10)
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		headers <- req.Header
+	}))
+	defer ts.Close()
+
+	testTrigger := data.Trigger{ID: "unsigned1", WebHooks: []data.WebHook{{URL: ts.URL}}}
+
+	//	Act
+	bp.FireQueue.Enqueue(trigger.FireRequest{Trigger: testTrigger, Source: triggersource.API, Time: time.Now()})
+	waitForHistory(t, bp, testTrigger.ID)
+
+	//	Assert
+	got := <-headers
+	if got.Get(signature.SignatureHeader) != "" || got.Get(signature.TimestampHeader) != "" {
+		t.Errorf("FireTrigger failed: Shouldn't have signed the webhook without a secret but got: %v", got)
+	}
+}
+
+func TestProcess_HandleAndProcess_Cancelled_WaitsForWorkers(t *testing.T) {
+
+	//	Arrange
+	db, err := data.NewManager(filepath.Join(t.TempDir(), "system.db"))
+	if err != nil {
+		t.Fatalf("NewManager failed: %s", err)
+	}
+	defer db.Close()
+
+	received := make(chan struct{}, 10)
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		received <- struct{}{}
+		<-req.Context().Done()
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	bp := trigger.BackgroundProcess{
+		FireQueue: trigger.NewFireQueue(trigger.FireQueueOptions{DB: db}),
+		DB:        db,
+		Metrics:   trigger.NewDeliveryMetrics(),
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		bp.HandleAndProcess(ctx)
+		close(stopped)
+	}()
+
+	testTrigger := data.Trigger{ID: "stopping1", WebHooks: []data.WebHook{{URL: ts.URL}}}
+	bp.FireQueue.Enqueue(trigger.FireRequest{Trigger: testTrigger, Source: triggersource.API, Time: time.Now()})
+	<-received
+
+	//	Act
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("HandleAndProcess failed: Should have stopped once the context was cancelled")
+	}
+
+	//	Assert
+	if status := bp.FireQueue.Status(); status.ActiveWorkers != 0 {
+		t.Errorf("HandleAndProcess failed: Should have waited for the workers to stop but got: %+v", status)
+	}
+
+	if remaining, _ := db.GetAllOutboxItems(); len(remaining) != 1 {
+		t.Errorf("HandleAndProcess failed: Should have left the interrupted delivery in the outbox but got: %v", len(remaining))
+	}
+}
@@ -0,0 +1,280 @@
+package trigger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/danesparza/fxtrigger/internal/data"
+	"github.com/rs/zerolog/log"
+)
+
+// Queue overflow policies (what happens when a trigger fires and the queue is full)
+const (
+	OverflowDropOldest = "dropoldest" // Drop the oldest queued fire to make room
+	OverflowDropNewest = "dropnewest" // Drop the new fire
+	OverflowBlock      = "block"      // Wait for room (up to the block timeout), then drop the new fire
+)
+
+// ErrQueueFull is returned when a fire is dropped because the queue is full
+var ErrQueueFull = errors.New("the fire queue is full")
+
+// Fire queue defaults
+const (
+	defaultQueueSize    = 100
+	defaultQueueWorkers = 4
+)
+
+// FireQueueOptions are the settings for a fire queue
+type FireQueueOptions struct {
+	Size         int           // The number of fires that can wait to be processed
+	Workers      int           // The number of fires that can be delivered at the same time
+	Overflow     string        // What to do when the queue is full (see the Overflow policies)
+	BlockTimeout time.Duration // How long to wait for room when the overflow policy is 'block'
+	DB           *data.Manager // Where fires are saved until they're processed.  If nil, they're only kept in memory
+}
+
+// QueueStatus is the current state of the fire queue
+type QueueStatus struct {
+	Depth         int    `json:"depth"`         // The number of fires waiting to be processed (including fires replayed at startup)
+	Capacity      int    `json:"capacity"`      // The number of fires that can wait to be processed
+	Overflow      string `json:"overflow"`      // What happens when the queue is full
+	Dropped       int    `json:"dropped"`       // The number of fires dropped because the queue was full
+	Workers       int    `json:"workers"`       // The number of fires that can be delivered at the same time
+	ActiveWorkers int    `json:"activeworkers"` // The number of fires being delivered right now
+}
+
+// FireQueue holds the trigger fires waiting to be processed.  Adding a fire never blocks
+// for long (so monitors and the API aren't stalled by a burst of fires)
+type FireQueue struct {
+	requests     chan FireRequest
+	replays      chan FireRequest // Fires replayed at startup.  They can't be dropped, so they wait here instead
+	overflow     string
+	blockTimeout time.Duration
+	workers      int
+	db           *data.Manager
+
+	dropped int
+	active  int
+	pending int // Fires that were added and haven't finished being processed
+	mutex   sync.Mutex
+}
+
+// ValidateOverflow makes sure the overflow policy is valid
+func ValidateOverflow(overflow string) error {
+	switch overflow {
+	case OverflowDropOldest, OverflowDropNewest, OverflowBlock:
+		return nil
+	default:
+		return fmt.Errorf("invalid overflow policy %q: must be one of: %v, %v, %v", overflow, OverflowDropOldest, OverflowDropNewest, OverflowBlock)
+	}
+}
+
+// NewFireQueue creates a fire queue.  Sizes that aren't set use the defaults, and
+// an invalid overflow policy drops the oldest fire
+func NewFireQueue(options FireQueueOptions) *FireQueue {
+	if options.Size <= 0 {
+		options.Size = defaultQueueSize
+	}
+
+	if options.Workers <= 0 {
+		options.Workers = defaultQueueWorkers
+	}
+
+	if ValidateOverflow(options.Overflow) != nil {
+		options.Overflow = OverflowDropOldest
+	}
+
+	return &FireQueue{
+		requests:     make(chan FireRequest, options.Size),
+		replays:      make(chan FireRequest),
+		overflow:     options.Overflow,
+		blockTimeout: options.BlockTimeout,
+		workers:      options.Workers,
+		db:           options.DB,
+	}
+}
+
+// Enqueue adds a fire to the queue.  If the fire is dropped (because the queue
+// is full), ErrQueueFull is returned
+func (q *FireQueue) Enqueue(req FireRequest) error {
+
+	//	Track the fire until it's processed (or dropped)
+	q.track(1)
+
+	//	Save it first, so it isn't lost if the service stops before it's processed
+	req = q.save(req)
+
+	//	If there's room, we're done
+	select {
+	case q.requests <- req:
+		return nil
+	default:
+	}
+
+	switch q.overflow {
+	case OverflowDropNewest:
+		q.drop(req)
+		return ErrQueueFull
+
+	case OverflowBlock:
+		timer := time.NewTimer(q.blockTimeout)
+		defer timer.Stop()
+
+		select {
+		case q.requests <- req:
+			return nil
+		case <-timer.C:
+			q.drop(req)
+			return ErrQueueFull
+		}
+
+	default:
+		//	Make room by dropping the oldest fire.  Other fires might be added (or
+		//	processed) at the same time, so keep trying until ours is added
+		for {
+			select {
+			case q.requests <- req:
+				return nil
+			default:
+			}
+
+			select {
+			case oldest := <-q.requests:
+				q.drop(oldest)
+			default:
+			}
+		}
+	}
+}
+
+// replay adds fires to the queue that were left over from a previous run.  They're never dropped:
+// they wait (in order) for the processor to accept them.  If the context is cancelled first, the
+// rest are left where they are (to be replayed at the next startup)
+func (q *FireQueue) replay(ctx context.Context, reqs []FireRequest) {
+
+	//	Track them all right away, so the queue status and Drain include them
+	q.track(len(reqs))
+
+	go func() {
+		for i, req := range reqs {
+			select {
+			case q.replays <- req:
+			case <-ctx.Done():
+				q.track(i - len(reqs))
+				return
+			}
+		}
+	}()
+}
+
+// save saves a fire that's waiting to be processed (if the queue has a database)
+func (q *FireQueue) save(req FireRequest) FireRequest {
+	if q.db == nil {
+		return req
+	}
+
+	saved, err := q.db.AddQueuedFire(data.QueuedFire{
+		Trigger:   req.Trigger,
+		Source:    req.Source,
+		Event:     req.Event,
+		Timestamp: req.Time,
+		Level:     req.Level,
+		Detail:    req.Detail,
+	})
+	if err != nil {
+		log.Err(err).Str("TriggerID", req.Trigger.ID).Str("Event", req.Event).Msg("Problem saving trigger fire.  It won't survive a restart")
+		return req
+	}
+
+	req.queuedFireID = saved.ID
+	return req
+}
+
+// forget removes a saved fire that won't be processed (because it was dropped or suppressed)
+func (q *FireQueue) forget(req FireRequest) {
+	if q.db == nil || req.queuedFireID == "" {
+		return
+	}
+
+	if err := q.db.DeleteQueuedFire(req.queuedFireID); err != nil {
+		log.Err(err).Str("TriggerID", req.Trigger.ID).Str("QueuedFireID", req.queuedFireID).Msg("Problem removing saved trigger fire")
+	}
+}
+
+// drop records a fire that was dropped because the queue was full
+func (q *FireQueue) drop(req FireRequest) {
+	q.mutex.Lock()
+	q.dropped++
+	q.pending--
+	q.mutex.Unlock()
+
+	q.forget(req)
+	log.Warn().Str("TriggerID", req.Trigger.ID).Str("Event", req.Event).Str("Overflow", q.overflow).Msg("Fire queue is full.  Dropping trigger fire")
+}
+
+// started records that a worker started delivering a fire
+func (q *FireQueue) started() {
+	q.mutex.Lock()
+	q.active++
+	q.mutex.Unlock()
+}
+
+// finished records that a worker finished delivering a fire
+func (q *FireQueue) finished() {
+	q.mutex.Lock()
+	q.active--
+	q.pending--
+	q.mutex.Unlock()
+}
+
+// track tracks the number of fires that haven't finished being processed
+func (q *FireQueue) track(delta int) {
+	q.mutex.Lock()
+	q.pending += delta
+	q.mutex.Unlock()
+}
+
+// drainCheckInterval is how often Drain checks if the queue is empty
+const drainCheckInterval = 50 * time.Millisecond
+
+// Drain waits until every fire added to the queue has been processed (including delivering its webhooks).
+// Fires held by do not disturb aren't waited for (they're saved, and held again at startup).
+// If the context is cancelled first, its error is returned
+func (q *FireQueue) Drain(ctx context.Context) error {
+	ticker := time.NewTicker(drainCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		q.mutex.Lock()
+		pending := q.pending
+		q.mutex.Unlock()
+
+		if pending <= 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Status returns the current state of the queue
+func (q *FireQueue) Status() QueueStatus {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return QueueStatus{
+		Depth:         q.pending - q.active,
+		Capacity:      cap(q.requests),
+		Overflow:      q.overflow,
+		Dropped:       q.dropped,
+		Workers:       q.workers,
+		ActiveWorkers: q.active,
+	}
+}
@@ -0,0 +1,234 @@
+package trigger_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/danesparza/fxtrigger/internal/data"
+	"github.com/danesparza/fxtrigger/internal/trigger"
+	"github.com/danesparza/fxtrigger/internal/triggersource"
+)
+
+func TestQueue_Enqueue_DropOldest_AddsNewest(t *testing.T) {
+
+	//	Arrange
+	queue := trigger.NewFireQueue(trigger.FireQueueOptions{Size: 2, Overflow: trigger.OverflowDropOldest})
+	req := trigger.FireRequest{Trigger: data.Trigger{ID: "unittest"}, Source: triggersource.API, Time: time.Now()}
+	queue.Enqueue(req)
+	queue.Enqueue(req)
+
+	//	Act
+	err := queue.Enqueue(req)
+
+	//	Assert
+	if err != nil {
+		t.Errorf("Enqueue failed: Should have added the newest fire but got: %v", err)
+	}
+
+	if status := queue.Status(); status.Depth != 2 || status.Dropped != 1 {
+		t.Errorf("Enqueue failed: Should have dropped the oldest fire but got: %+v", status)
+	}
+}
+
+func TestQueue_Enqueue_DropNewest_QueueFull(t *testing.T) {
+
+	//	Arrange
+	queue := trigger.NewFireQueue(trigger.FireQueueOptions{Size: 1, Overflow: trigger.OverflowDropNewest})
+	req := trigger.FireRequest{Trigger: data.Trigger{ID: "unittest"}, Source: triggersource.API, Time: time.Now()}
+	queue.Enqueue(req)
+
+	//	Act
+	err := queue.Enqueue(req)
+
+	//	Assert
+	if !errors.Is(err, trigger.ErrQueueFull) {
+		t.Errorf("Enqueue failed: Should have returned ErrQueueFull but got: %v", err)
+	}
+
+	if status := queue.Status(); status.Depth != 1 || status.Dropped != 1 {
+		t.Errorf("Enqueue failed: Should have dropped the newest fire but got: %+v", status)
+	}
+}
+
+func TestQueue_Enqueue_BlockTimesOut_QueueFull(t *testing.T) {
+
+	//	Arrange
+	queue := trigger.NewFireQueue(trigger.FireQueueOptions{Size: 1, Overflow: trigger.OverflowBlock, BlockTimeout: 50 * time.Millisecond})
+	req := trigger.FireRequest{Trigger: data.Trigger{ID: "unittest"}, Source: triggersource.API, Time: time.Now()}
+	queue.Enqueue(req)
+
+	//	Act
+	start := time.Now()
+	err := queue.Enqueue(req)
+	waited := time.Since(start)
+
+	//	Assert
+	if !errors.Is(err, trigger.ErrQueueFull) {
+		t.Errorf("Enqueue failed: Should have returned ErrQueueFull but got: %v", err)
+	}
+
+	if waited < 50*time.Millisecond {
+		t.Errorf("Enqueue failed: Should have waited for room but only waited: %v", waited)
+	}
+}
+
+func TestQueue_HandleAndProcess_BurstOfFires_AllDelivered(t *testing.T) {
+
+	//	Arrange
+	bp := newTestProcess(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer ts.Close()
+
+	testTrigger := data.Trigger{ID: "burst1", WebHooks: []data.WebHook{{URL: ts.URL}}}
+
+	//	Act
+	for i := 0; i < 20; i++ {
+		if err := bp.FireQueue.Enqueue(trigger.FireRequest{Trigger: testTrigger, Source: triggersource.API, Time: time.Now()}); err != nil {
+			t.Fatalf("Enqueue failed: %v", err)
+		}
+	}
+
+	//	Assert
+	for i := 0; i < 50; i++ {
+		items, _ := bp.DB.GetHistory(data.HistoryFilter{TriggerID: testTrigger.ID})
+		if len(items) == 20 {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Errorf("HandleAndProcess failed: Should have delivered every fire")
+}
+
+func TestQueue_Drain_WaitsForDeliveries(t *testing.T) {
+
+	//	Arrange
+	bp := newTestProcess(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer ts.Close()
+
+	testTrigger := data.Trigger{ID: "drain1", WebHooks: []data.WebHook{{URL: ts.URL}}}
+	for i := 0; i < 3; i++ {
+		bp.FireQueue.Enqueue(trigger.FireRequest{Trigger: testTrigger, Source: triggersource.API, Time: time.Now()})
+	}
+
+	//	Act
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := bp.FireQueue.Drain(ctx)
+
+	//	Assert
+	if err != nil {
+		t.Fatalf("Drain failed: %v", err)
+	}
+
+	items, _ := bp.DB.GetHistory(data.HistoryFilter{TriggerID: testTrigger.ID})
+	if len(items) != 3 {
+		t.Errorf("Drain failed: Should have finished every delivery but got: %v", len(items))
+	}
+}
+
+func TestQueue_Drain_GracePeriodEnds_ReturnsError(t *testing.T) {
+
+	//	Arrange
+	bp := newTestProcess(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		time.Sleep(1 * time.Second)
+	}))
+	defer ts.Close()
+
+	testTrigger := data.Trigger{ID: "drain2", WebHooks: []data.WebHook{{URL: ts.URL}}}
+	bp.FireQueue.Enqueue(trigger.FireRequest{Trigger: testTrigger, Source: triggersource.API, Time: time.Now()})
+
+	//	Act
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := bp.FireQueue.Drain(ctx)
+
+	//	Assert
+	if err == nil {
+		t.Errorf("Drain failed: Should have returned an error when the grace period ended")
+	}
+}
+
+func TestQueue_Enqueue_ServiceStopsBeforeProcessed_ReplayedAtStartup(t *testing.T) {
+
+	//	Arrange
+	db, err := data.NewManager(filepath.Join(t.TempDir(), "system.db"))
+	if err != nil {
+		t.Fatalf("NewManager failed: %s", err)
+	}
+	defer db.Close()
+
+	hooks := make(chan string, 10)
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		hooks <- req.URL.Path
+	}))
+	defer ts.Close()
+
+	//	A fire is queued, but the service stops before it's processed (and the queue is lost)
+	testTrigger := data.Trigger{ID: "saved1", WebHooks: []data.WebHook{{URL: ts.URL + "/saved"}}}
+	trigger.NewFireQueue(trigger.FireQueueOptions{DB: db}).Enqueue(trigger.FireRequest{Trigger: testTrigger, Source: triggersource.API, Time: time.Now()})
+	saved, _ := db.GetAllQueuedFires()
+
+	//	Act
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bp := trigger.BackgroundProcess{
+		FireQueue: trigger.NewFireQueue(trigger.FireQueueOptions{DB: db}),
+		DB:        db,
+		Metrics:   trigger.NewDeliveryMetrics(),
+	}
+	go bp.HandleAndProcess(ctx)
+	bp.ReplayOutbox(ctx)
+	waitForHistory(t, bp, testTrigger.ID)
+	remaining, _ := db.GetAllQueuedFires()
+
+	//	Assert
+	if len(saved) != 1 {
+		t.Errorf("Enqueue failed: Should have saved the fire but got: %v", len(saved))
+	}
+
+	if got := <-hooks; got != "/saved" {
+		t.Errorf("ReplayOutbox failed: Should have sent the saved fire but got: %v", got)
+	}
+
+	if len(remaining) != 0 {
+		t.Errorf("ReplayOutbox failed: Should have removed the processed fire but got: %v", len(remaining))
+	}
+}
+
+func TestQueue_Enqueue_Dropped_SavedFireRemoved(t *testing.T) {
+
+	//	Arrange
+	db, err := data.NewManager(filepath.Join(t.TempDir(), "system.db"))
+	if err != nil {
+		t.Fatalf("NewManager failed: %s", err)
+	}
+	defer db.Close()
+
+	queue := trigger.NewFireQueue(trigger.FireQueueOptions{Size: 1, Overflow: trigger.OverflowDropNewest, DB: db})
+	req := trigger.FireRequest{Trigger: data.Trigger{ID: "unittest"}, Source: triggersource.API, Time: time.Now()}
+	queue.Enqueue(req)
+
+	//	Act
+	queue.Enqueue(req)
+	saved, _ := db.GetAllQueuedFires()
+
+	//	Assert
+	if len(saved) != 1 {
+		t.Errorf("Enqueue failed: Should have only kept the saved fire that's still queued but got: %v", len(saved))
+	}
+}
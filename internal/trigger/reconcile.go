@@ -0,0 +1,127 @@
+package trigger
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/danesparza/fxtrigger/internal/data"
+	"github.com/danesparza/fxtrigger/internal/triggerevent"
+	"github.com/rs/zerolog/log"
+)
+
+// ReconcileAll reconciles the monitors for every trigger
+const ReconcileAll = ""
+
+// ReconcileRequest is a request to bring the monitors for a trigger in line with the stored trigger
+type ReconcileRequest struct {
+	TriggerID string        // The trigger id to reconcile (or ReconcileAll)
+	Done      chan struct{} // Closed once the monitors have been reconciled.  Optional
+}
+
+// reconcile brings the running monitors in line with the stored triggers: enabled triggers get a
+// monitor running their current settings, and monitors for disabled or removed triggers are stopped.
+// Pass ReconcileAll to reconcile every trigger, or a trigger id to reconcile just that trigger
+func (bp BackgroundProcess) reconcile(systemctx context.Context, pins *pinReader, monitors *monitoredTriggersMap, triggerID string) {
+
+	//	Get the desired state
+	desired := make(map[string]data.Trigger)
+	if triggerID == ReconcileAll {
+		allTriggers, err := bp.DB.GetAllTriggers()
+		if err != nil {
+			log.Err(err).Msg("Problem getting all triggers to reconcile monitors")
+			return
+		}
+
+		for _, t := range allTriggers {
+			if t.Enabled {
+				desired[t.ID] = t
+			}
+		}
+	} else {
+		t, err := bp.DB.GetTrigger(triggerID)
+		if err == nil && t.ID == triggerID && t.Enabled {
+			desired[t.ID] = t
+		}
+	}
+
+	//	Stop the monitors that shouldn't be running (or are running old settings)
+	for id, running := range monitors.m {
+		if triggerID != ReconcileAll && id != triggerID {
+			continue
+		}
+
+		want, exists := desired[id]
+		if exists && reflect.DeepEqual(want, running.trigger) && !stopped(running) {
+			//	Already running the current settings
+			delete(desired, id)
+			continue
+		}
+
+		bp.stopMonitor(systemctx, monitors, id)
+	}
+
+	//	Start the monitors that should be running
+	for _, t := range desired {
+		bp.startMonitor(systemctx, pins, monitors, t)
+	}
+}
+
+// startMonitor starts a monitor for the trigger in the background
+func (bp BackgroundProcess) startMonitor(systemctx context.Context, pins *pinReader, monitors *monitoredTriggersMap, t data.Trigger) {
+
+	//	Create a cancelable context from the passed (system) context
+	ctx, cancel := context.WithCancel(systemctx)
+	running := &monitor{trigger: t, cancel: cancel, done: make(chan struct{})}
+	monitors.m[t.ID] = running
+
+	go func() {
+		defer close(running.done)
+		defer cancel()
+
+		//	Run the monitor.  If it fails, let System triggers know
+		if err := bp.runMonitor(ctx, pins, t); err != nil {
+			log.Err(err).Str("TriggerID", t.ID).Msg("Monitor failed")
+			bp.FireSystemEvent(triggerevent.MonitorFailure, fmt.Sprintf("%v: %v", t.Name, err))
+		}
+	}()
+
+	log.Debug().Str("TriggerID", t.ID).Msg("Monitoring started")
+}
+
+// stopMonitor stops the monitor for a trigger and waits for it to finish (so its
+// GPIO pin is released before another monitor is started)
+func (bp BackgroundProcess) stopMonitor(systemctx context.Context, monitors *monitoredTriggersMap, triggerID string) {
+	running, exists := monitors.m[triggerID]
+	if !exists {
+		return
+	}
+
+	running.cancel()
+	select {
+	case <-running.done:
+	case <-systemctx.Done():
+	}
+
+	delete(monitors.m, triggerID)
+	log.Debug().Str("TriggerID", triggerID).Msg("Monitoring stopped")
+}
+
+// stopAll stops every monitor and waits for them to finish
+func (monitors *monitoredTriggersMap) stopAll() {
+	for id, running := range monitors.m {
+		running.cancel()
+		<-running.done
+		delete(monitors.m, id)
+	}
+}
+
+// stopped returns true if the monitor has stopped on its own (because of an error)
+func stopped(m *monitor) bool {
+	select {
+	case <-m.done:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,123 @@
+package trigger
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/danesparza/fxtrigger/internal/data"
+	"github.com/rs/zerolog/log"
+)
+
+// Webhook retry conditions
+const (
+	// RetryOnNetwork retries when the webhook couldn't be sent or no response was received
+	RetryOnNetwork = "network"
+
+	// RetryOn4xx retries when the webhook gets a 4xx response
+	RetryOn4xx = "4xx"
+
+	// RetryOn5xx retries when the webhook gets a 5xx response
+	RetryOn5xx = "5xx"
+)
+
+// Webhook delivery defaults
+const (
+	defaultInitialBackoff = 500 * time.Millisecond
+	defaultMaxBackoff     = 30 * time.Second
+	defaultTimeout        = 10 * time.Second
+)
+
+// defaultRetryOn are the retry conditions used when a webhook doesn't specify any
+var defaultRetryOn = []string{RetryOnNetwork, RetryOn5xx}
+
+// deliverWebHook sends a webhook, retrying with backoff according to the webhook settings.
+// Each attempt is tracked in the delivery metrics under the metrics URL
+func (bp BackgroundProcess) deliverWebHook(ctx context.Context, triggerID, metricsURL string, hook data.WebHook) data.DeliveryResult {
+
+	retval := data.DeliveryResult{URL: hook.URL}
+
+	for attempt := 0; attempt <= hook.MaxRetries; attempt++ {
+
+		//	Wait before retrying (but not before the first attempt)
+		if attempt > 0 {
+			delay := backoff(hook, attempt)
+			log.Debug().Str("TriggerID", triggerID).Str("HookUrl", hook.URL).Int("Attempt", attempt+1).Dur("Delay", delay).Msg("Retrying trigger/hook")
+
+			select {
+			case <-ctx.Done():
+				return retval
+			case <-time.After(delay):
+			}
+		}
+
+		retval = bp.sendWebHook(ctx, triggerID, metricsURL, hook)
+		retval.Attempts = attempt + 1
+
+		if retval.Succeeded() || !shouldRetry(hook, retval) {
+			break
+		}
+	}
+
+	return retval
+}
+
+// shouldRetry returns true if the webhook settings call for a retry of the result
+func shouldRetry(hook data.WebHook, result data.DeliveryResult) bool {
+	retryOn := hook.RetryOn
+	if len(retryOn) == 0 {
+		retryOn = defaultRetryOn
+	}
+
+	for _, condition := range retryOn {
+		switch condition {
+		case RetryOnNetwork:
+			if result.StatusCode == 0 {
+				return true
+			}
+		case RetryOn4xx:
+			if result.StatusCode >= 400 && result.StatusCode < 500 {
+				return true
+			}
+		case RetryOn5xx:
+			if result.StatusCode >= 500 && result.StatusCode < 600 {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// backoff returns the delay before a retry attempt: exponential growth from the
+// initial backoff, capped at the max backoff, with jitter over the upper half
+func backoff(hook data.WebHook, attempt int) time.Duration {
+	initial := defaultInitialBackoff
+	if hook.InitialBackoff > 0 {
+		initial = time.Duration(hook.InitialBackoff) * time.Millisecond
+	}
+
+	max := defaultMaxBackoff
+	if hook.MaxBackoff > 0 {
+		max = time.Duration(hook.MaxBackoff) * time.Millisecond
+	}
+
+	delay := initial
+	for i := 1; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// timeout returns the per attempt timeout for the webhook
+func timeout(hook data.WebHook) time.Duration {
+	if hook.Timeout > 0 {
+		return time.Duration(hook.Timeout) * time.Millisecond
+	}
+	return defaultTimeout
+}
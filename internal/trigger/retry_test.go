@@ -0,0 +1,136 @@
+package trigger_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/danesparza/fxtrigger/internal/data"
+	"github.com/danesparza/fxtrigger/internal/trigger"
+	"github.com/danesparza/fxtrigger/internal/triggersource"
+)
+
+// newTestProcess creates a background process (with its own database) and starts the trigger processor
+func newTestProcess(t *testing.T) trigger.BackgroundProcess {
+	t.Helper()
+
+	db, err := data.NewManager(filepath.Join(t.TempDir(), "system.db"))
+	if err != nil {
+		t.Fatalf("NewManager failed: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(func() {
+		cancel()
+		db.Close()
+	})
+
+	bp := trigger.BackgroundProcess{
+		FireQueue: trigger.NewFireQueue(trigger.FireQueueOptions{DB: db}),
+		DB:        db,
+		Metrics:   trigger.NewDeliveryMetrics(),
+	}
+	go bp.HandleAndProcess(ctx)
+
+	return bp
+}
+
+// waitForHistory waits for the trigger to have a history item and returns it
+func waitForHistory(t *testing.T, bp trigger.BackgroundProcess, triggerID string) data.HistoryItem {
+	t.Helper()
+
+	for i := 0; i < 50; i++ {
+		items, _ := bp.DB.GetHistory(data.HistoryFilter{TriggerID: triggerID})
+		if len(items) > 0 {
+			return items[0]
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	t.Fatalf("Timed out waiting for history for trigger %s", triggerID)
+	return data.HistoryItem{}
+}
+
+func TestRetry_ServerErrorThenSuccess_Retries(t *testing.T) {
+
+	//	Arrange
+	bp := newTestProcess(t)
+
+	calls := int32(0)
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+
+	testTrigger := data.Trigger{ID: "retry1", WebHooks: []data.WebHook{{URL: ts.URL, MaxRetries: 5, InitialBackoff: 10, MaxBackoff: 20}}}
+
+	//	Act
+	bp.FireQueue.Enqueue(trigger.FireRequest{Trigger: testTrigger, Source: triggersource.API, Time: time.Now()})
+	gotItem := waitForHistory(t, bp, testTrigger.ID)
+
+	//	Assert
+	if gotItem.WebHooks[0].Attempts != 3 {
+		t.Errorf("Retry failed: Should have taken 3 attempts but got: %+v", gotItem.WebHooks[0])
+	}
+
+	if !gotItem.WebHooks[0].Succeeded() {
+		t.Errorf("Retry failed: Should have eventually succeeded but got: %+v", gotItem.WebHooks[0])
+	}
+}
+
+func TestRetry_ClientErrorNotRetryable_SingleAttempt(t *testing.T) {
+
+	//	Arrange
+	bp := newTestProcess(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusBadRequest)
+	}))
+	defer ts.Close()
+
+	testTrigger := data.Trigger{ID: "retry2", WebHooks: []data.WebHook{{URL: ts.URL, MaxRetries: 5, InitialBackoff: 10}}}
+
+	//	Act
+	bp.FireQueue.Enqueue(trigger.FireRequest{Trigger: testTrigger, Source: triggersource.API, Time: time.Now()})
+	gotItem := waitForHistory(t, bp, testTrigger.ID)
+
+	//	Assert
+	if gotItem.WebHooks[0].Attempts != 1 {
+		t.Errorf("Retry failed: Should not retry a 4xx response by default but got: %+v", gotItem.WebHooks[0])
+	}
+
+	if gotItem.WebHooks[0].StatusCode != http.StatusBadRequest {
+		t.Errorf("Retry failed: Should have recorded the status code but got: %+v", gotItem.WebHooks[0])
+	}
+}
+
+func TestRetry_NetworkErrorRetriesExhausted_Fails(t *testing.T) {
+
+	//	Arrange
+	bp := newTestProcess(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}))
+	url := ts.URL
+	ts.Close() //	Nothing is listening anymore
+
+	testTrigger := data.Trigger{ID: "retry3", WebHooks: []data.WebHook{{URL: url, MaxRetries: 2, InitialBackoff: 10, Timeout: 500}}}
+
+	//	Act
+	bp.FireQueue.Enqueue(trigger.FireRequest{Trigger: testTrigger, Source: triggersource.API, Time: time.Now()})
+	gotItem := waitForHistory(t, bp, testTrigger.ID)
+
+	//	Assert
+	if gotItem.WebHooks[0].Attempts != 3 {
+		t.Errorf("Retry failed: Should have made the first attempt and 2 retries but got: %+v", gotItem.WebHooks[0])
+	}
+
+	if gotItem.WebHooks[0].Error == "" {
+		t.Errorf("Retry failed: Should have recorded the network error but got: %+v", gotItem.WebHooks[0])
+	}
+}
@@ -0,0 +1,76 @@
+package trigger
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/danesparza/fxtrigger/internal/data"
+	"github.com/danesparza/fxtrigger/internal/triggerevent"
+	"github.com/danesparza/fxtrigger/internal/triggersource"
+	"github.com/robfig/cron/v3"
+	"github.com/rs/zerolog/log"
+)
+
+// scheduleParser parses standard (5 field) cron expressions, and descriptors like @hourly or @every 5m
+var scheduleParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// ParseSchedule parses the cron expression and timezone for a Time trigger.  If the timezone
+// isn't set, the local timezone is used
+func ParseSchedule(expression, timezone string) (cron.Schedule, *time.Location, error) {
+	location := time.Local
+	if timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid timezone %q: %v", timezone, err)
+		}
+		location = loc
+	}
+
+	schedule, err := scheduleParser.Parse(expression)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid schedule %q: %v", expression, err)
+	}
+
+	return schedule, location, nil
+}
+
+// NextFireTime returns the next time a Time trigger fires after the given time
+func NextFireTime(t data.Trigger, after time.Time) (time.Time, error) {
+	schedule, location, err := ParseSchedule(t.Schedule, t.Timezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return schedule.Next(after.In(location)), nil
+}
+
+// runSchedule fires a Time trigger at its scheduled times.  It returns when the context is cancelled
+// (or right away, with an error, if the schedule isn't valid)
+func (bp BackgroundProcess) runSchedule(ctx context.Context, req data.Trigger) error {
+
+	schedule, location, err := ParseSchedule(req.Schedule, req.Timezone)
+	if err != nil {
+		return err
+	}
+
+	log.Debug().Str("TriggerID", req.ID).Str("Schedule", req.Schedule).Str("Timezone", location.String()).Msg("Schedule started")
+
+	for {
+		next := schedule.Next(time.Now().In(location))
+		if next.IsZero() {
+			log.Warn().Str("TriggerID", req.ID).Str("Schedule", req.Schedule).Msg("Schedule never fires again.  Stopping")
+			return nil
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+			log.Debug().Str("TriggerID", req.ID).Time("ScheduledTime", next).Msg("Scheduled time reached.  Firing event")
+			bp.FireQueue.Enqueue(FireRequest{Trigger: req, Source: triggersource.Schedule, Event: triggerevent.Scheduled, Time: time.Now()})
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package trigger_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/danesparza/fxtrigger/internal/data"
+	"github.com/danesparza/fxtrigger/internal/trigger"
+	"github.com/danesparza/fxtrigger/internal/triggertype"
+)
+
+func TestSchedule_NextFireTime_UsesTimezone(t *testing.T) {
+
+	//	Arrange
+	timeTrigger := data.Trigger{Type: triggertype.Time, Schedule: "30 7 * * *", Timezone: "America/New_York"}
+	after := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC) // 8:00am in New York
+
+	//	Act
+	next, err := trigger.NextFireTime(timeTrigger, after)
+
+	//	Assert
+	if err != nil {
+		t.Fatalf("NextFireTime failed: %s", err)
+	}
+
+	expected := time.Date(2024, 6, 2, 11, 30, 0, 0, time.UTC) // 7:30am the next day in New York
+	if !next.Equal(expected) {
+		t.Errorf("NextFireTime failed: Expected %v but got %v", expected, next.UTC())
+	}
+}
+
+func TestSchedule_ParseSchedule_Descriptor_Successful(t *testing.T) {
+
+	//	Arrange
+	after := time.Date(2024, 6, 1, 12, 10, 0, 0, time.UTC)
+
+	//	Act
+	schedule, location, err := trigger.ParseSchedule("@hourly", "UTC")
+
+	//	Assert
+	if err != nil {
+		t.Fatalf("ParseSchedule failed: %s", err)
+	}
+
+	if next := schedule.Next(after.In(location)); !next.Equal(time.Date(2024, 6, 1, 13, 0, 0, 0, time.UTC)) {
+		t.Errorf("ParseSchedule failed: Unexpected next time: %v", next)
+	}
+}
+
+func TestSchedule_ParseSchedule_Invalid_ReturnsError(t *testing.T) {
+
+	//	Arrange
+	tests := []struct {
+		schedule string
+		timezone string
+	}{
+		{"not a schedule", ""},
+		{"* * * * * *", ""},
+		{"@hourly", "Mars/Olympus_Mons"},
+	}
+
+	for _, test := range tests {
+		//	Act
+		_, _, err := trigger.ParseSchedule(test.schedule, test.timezone)
+
+		//	Assert
+		if err == nil {
+			t.Errorf("ParseSchedule failed: Should have returned an error for %q / %q", test.schedule, test.timezone)
+		}
+	}
+}
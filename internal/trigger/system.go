@@ -0,0 +1,144 @@
+package trigger
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danesparza/fxtrigger/internal/data"
+	"github.com/danesparza/fxtrigger/internal/triggerevent"
+	"github.com/danesparza/fxtrigger/internal/triggersource"
+	"github.com/danesparza/fxtrigger/internal/triggertype"
+	"github.com/rs/zerolog/log"
+)
+
+// SystemEvents are the system events that System triggers can use
+var SystemEvents = []string{
+	triggerevent.Startup,
+	triggerevent.Shutdown,
+	triggerevent.NetworkUp,
+	triggerevent.MonitorFailure,
+	triggerevent.DeliveryFailure,
+}
+
+// networkCheckInterval is how often the network interfaces are checked
+const networkCheckInterval = 10 * time.Second
+
+// FireSystemEvent fires the enabled System triggers for a system event, without waiting for them to be sent
+func (bp BackgroundProcess) FireSystemEvent(event, detail string) {
+	for _, req := range bp.systemFireRequests(event, detail) {
+		go bp.FireQueue.Enqueue(req)
+	}
+}
+
+// DeliverSystemEvent sends the webhooks for the enabled System triggers for a system event, and waits
+// until they're finished (or the context is cancelled).  Use this when the service is about to stop
+func (bp BackgroundProcess) DeliverSystemEvent(ctx context.Context, event, detail string) {
+	wg := sync.WaitGroup{}
+
+	for _, req := range bp.systemFireRequests(event, detail) {
+		wg.Add(1)
+		go func(req FireRequest) {
+			defer wg.Done()
+			bp.deliver(ctx, req.Trigger.ID, bp.addToOutbox(req))
+		}(req)
+	}
+
+	wg.Wait()
+}
+
+// systemFireRequests returns the fire requests for the enabled System triggers for a system event
+func (bp BackgroundProcess) systemFireRequests(event, detail string) []FireRequest {
+	retval := []FireRequest{}
+
+	allTriggers, err := bp.DB.GetAllTriggers()
+	if err != nil {
+		log.Err(err).Str("Event", event).Msg("Problem getting triggers for system event")
+		return retval
+	}
+
+	for _, t := range allTriggers {
+		if t.Enabled && t.Type == triggertype.System && t.SystemEvent == event {
+			retval = append(retval, FireRequest{Trigger: t, Source: triggersource.System, Event: event, Time: time.Now(), Detail: detail})
+		}
+	}
+
+	if len(retval) > 0 {
+		log.Debug().Str("Event", event).Str("Detail", detail).Int("TriggerCount", len(retval)).Msg("Firing system event")
+	}
+
+	return retval
+}
+
+// WatchNetwork fires the network up event whenever the network becomes available
+// (including when the service starts).  It returns when the context is cancelled
+func (bp BackgroundProcess) WatchNetwork(ctx context.Context) {
+	ticker := time.NewTicker(networkCheckInterval)
+	defer ticker.Stop()
+
+	up := false
+	for {
+		if nowUp := networkUp(); nowUp != up {
+			up = nowUp
+			if up {
+				log.Info().Msg("Network is up")
+				bp.FireSystemEvent(triggerevent.NetworkUp, "")
+			} else {
+				log.Warn().Msg("Network is down")
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// networkUp returns true if a (non loopback) network interface is up and has a routable address
+func networkUp() bool {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return false
+	}
+
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+
+		for _, addr := range addrs {
+			if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.IsGlobalUnicast() {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// deliveryFailureDetail describes the webhooks that failed for a trigger
+func deliveryFailureDetail(triggerName string, results []data.DeliveryResult) string {
+	failures := []string{}
+	for _, result := range results {
+		switch {
+		case result.Succeeded():
+			continue
+		case result.Error != "":
+			failures = append(failures, fmt.Sprintf("%v: %v: %v", triggerName, result.URL, result.Error))
+		default:
+			failures = append(failures, fmt.Sprintf("%v: %v: status %v", triggerName, result.URL, result.StatusCode))
+		}
+	}
+
+	return strings.Join(failures, "; ")
+}
@@ -0,0 +1,97 @@
+package trigger_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danesparza/fxtrigger/internal/data"
+	"github.com/danesparza/fxtrigger/internal/trigger"
+	"github.com/danesparza/fxtrigger/internal/triggerevent"
+	"github.com/danesparza/fxtrigger/internal/triggersource"
+	"github.com/danesparza/fxtrigger/internal/triggertype"
+)
+
+func TestSystem_DeliverSystemEvent_WaitsForWebHooks(t *testing.T) {
+
+	//	Arrange
+	bp := newTestProcess(t)
+
+	bodies := make(chan string, 10)
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		bodies <- string(body)
+	}))
+	defer ts.Close()
+
+	bp.DB.CreateTrigger(data.Trigger{
+		Name:        "Shutdown ping",
+		Type:        triggertype.System,
+		SystemEvent: triggerevent.Shutdown,
+		WebHooks:    []data.WebHook{{URL: ts.URL, Body: []byte(`{{.Event}} {{.Detail}}`)}},
+	})
+	bp.DB.CreateTrigger(data.Trigger{
+		Name:        "Startup ping",
+		Type:        triggertype.System,
+		SystemEvent: triggerevent.Startup,
+		WebHooks:    []data.WebHook{{URL: ts.URL}},
+	})
+
+	//	Act
+	bp.DeliverSystemEvent(context.Background(), triggerevent.Shutdown, "terminated")
+
+	//	Assert
+	if len(bodies) != 1 {
+		t.Fatalf("DeliverSystemEvent failed: Should have sent one webhook before returning but sent: %v", len(bodies))
+	}
+
+	if body := <-bodies; body != "shutdown terminated" {
+		t.Errorf("DeliverSystemEvent failed: Unexpected body: %v", body)
+	}
+}
+
+func TestSystem_DeliveryFailure_FiresSystemEvent(t *testing.T) {
+
+	//	Arrange
+	bp := newTestProcess(t)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusBadRequest)
+	}))
+	defer failing.Close()
+
+	bodies := make(chan string, 10)
+	alerts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		bodies <- string(body)
+	}))
+	defer alerts.Close()
+
+	bp.DB.CreateTrigger(data.Trigger{
+		Name:        "Delivery alert",
+		Type:        triggertype.System,
+		SystemEvent: triggerevent.DeliveryFailure,
+		WebHooks:    []data.WebHook{{URL: alerts.URL, Body: []byte(`{{.Detail}}`)}},
+	})
+	motion, _ := bp.DB.CreateTrigger(data.Trigger{
+		Name:     "Front door",
+		WebHooks: []data.WebHook{{URL: failing.URL}},
+	})
+
+	//	Act
+	bp.FireQueue.Enqueue(trigger.FireRequest{Trigger: motion, Source: triggersource.API, Event: triggerevent.Activated, Time: time.Now()})
+
+	//	Assert
+	select {
+	case body := <-bodies:
+		if !strings.Contains(body, "Front door") || !strings.Contains(body, "status 400") {
+			t.Errorf("FireTrigger failed: Unexpected delivery failure detail: %v", body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("FireTrigger failed: Timed out waiting for the delivery failure event")
+	}
+}
@@ -0,0 +1,124 @@
+package trigger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"text/template"
+	"time"
+
+	"github.com/danesparza/fxtrigger/internal/data"
+)
+
+// EventContext is the information about a trigger firing that's available
+// to webhook URL, header and body templates.  For example: {{.Name}} or {{json .Name}}
+type EventContext struct {
+	TriggerID string    // The trigger id
+	Name      string    // The trigger name
+	GPIOPin   int       // The GPIO pin the trigger is on
+	Timestamp time.Time // When the trigger fired
+	Source    string    // What caused the trigger to fire (see triggersource)
+	Event     string    // The trigger event (see triggerevent)
+	Level     string    // The pin level when the trigger fired (high or low).  Empty if it wasn't fired by a pin
+	Detail    string    // Details about a system event (like the monitor or webhook that failed).  Empty for other events
+	FireCount int       // The number of times the trigger has fired since the service started
+}
+
+// templateFuncs are the extra functions available to webhook templates
+var templateFuncs = template.FuncMap{
+	//	json encodes a value, so it can safely be used in a JSON body
+	"json": func(v any) (string, error) {
+		encoded, err := json.Marshal(v)
+		return string(encoded), err
+	},
+}
+
+// NewEventContext creates the template context for a fire request
+func NewEventContext(req FireRequest) EventContext {
+	return EventContext{
+		TriggerID: req.Trigger.ID,
+		Name:      req.Trigger.Name,
+		GPIOPin:   req.Trigger.GPIOPin,
+		Timestamp: req.Time,
+		Source:    req.Source,
+		Event:     req.Event,
+		Level:     req.Level,
+		Detail:    req.Detail,
+		FireCount: req.FireCount,
+	}
+}
+
+// ValidateWebHookTemplates makes sure the webhook URL, headers and body are valid templates
+func ValidateWebHookTemplates(hook data.WebHook) error {
+	if _, err := parseTemplate("url", hook.URL); err != nil {
+		return err
+	}
+
+	for k, v := range hook.Headers {
+		if _, err := parseTemplate("header "+k, v); err != nil {
+			return err
+		}
+	}
+
+	if _, err := parseTemplate("body", string(hook.Body)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// renderWebHook returns a copy of the webhook with the URL, headers and body rendered for the event
+func renderWebHook(hook data.WebHook, event EventContext) (data.WebHook, error) {
+	retval := hook
+
+	url, err := renderTemplate("url", hook.URL, event)
+	if err != nil {
+		return hook, err
+	}
+	retval.URL = url
+
+	if len(hook.Headers) > 0 {
+		retval.Headers = make(map[string]string)
+		for k, v := range hook.Headers {
+			header, err := renderTemplate("header "+k, v, event)
+			if err != nil {
+				return hook, err
+			}
+			retval.Headers[k] = header
+		}
+	}
+
+	if len(hook.Body) > 0 {
+		body, err := renderTemplate("body", string(hook.Body), event)
+		if err != nil {
+			return hook, err
+		}
+		retval.Body = []byte(body)
+	}
+
+	return retval, nil
+}
+
+// parseTemplate parses a single webhook template
+func parseTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("problem parsing the %s template: %v", name, err)
+	}
+	return tmpl, nil
+}
+
+// renderTemplate renders a single webhook template for the event
+func renderTemplate(name, text string, event EventContext) (string, error) {
+	tmpl, err := parseTemplate(name, text)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, event); err != nil {
+		return "", fmt.Errorf("problem rendering the %s template: %v", name, err)
+	}
+
+	return buf.String(), nil
+}
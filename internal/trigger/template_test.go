@@ -0,0 +1,72 @@
+package trigger_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/danesparza/fxtrigger/internal/data"
+	"github.com/danesparza/fxtrigger/internal/trigger"
+	"github.com/danesparza/fxtrigger/internal/triggersource"
+)
+
+func TestTemplate_FireTrigger_RendersEventContext(t *testing.T) {
+
+	//	Arrange
+	bp := newTestProcess(t)
+
+	received := make(chan *http.Request, 10)
+	bodies := make(chan string, 10)
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		received <- req
+		bodies <- string(body)
+	}))
+	defer ts.Close()
+
+	testTrigger := data.Trigger{ID: "template1", Name: `Front "door"`, GPIOPin: 17, WebHooks: []data.WebHook{{
+		URL:         ts.URL + "/{{.TriggerID}}/{{.FireCount}}",
+		Headers:     map[string]string{"X-Source": "{{.Source}}"},
+		ContentType: "text/plain",
+		Body:        []byte(`{"name": {{json .Name}}, "pin": {{.GPIOPin}}, "level": "{{.Level}}"}`),
+	}}}
+
+	//	Act
+	bp.FireQueue.Enqueue(trigger.FireRequest{Trigger: testTrigger, Source: triggersource.GPIO, Time: time.Now(), Level: "high"})
+	waitForHistory(t, bp, testTrigger.ID)
+	gotReq := <-received
+	gotBody := <-bodies
+
+	//	Assert
+	if gotReq.URL.Path != "/template1/1" {
+		t.Errorf("FireTrigger failed: Should have rendered the url but got: %v", gotReq.URL.Path)
+	}
+
+	if gotReq.Header.Get("X-Source") != triggersource.GPIO {
+		t.Errorf("FireTrigger failed: Should have rendered the header but got: %v", gotReq.Header.Get("X-Source"))
+	}
+
+	if gotReq.Header.Get("Content-Type") != "text/plain" {
+		t.Errorf("FireTrigger failed: Should have used the content type override but got: %v", gotReq.Header.Get("Content-Type"))
+	}
+
+	if gotBody != `{"name": "Front \"door\"", "pin": 17, "level": "high"}` {
+		t.Errorf("FireTrigger failed: Should have rendered the body but got: %v", gotBody)
+	}
+}
+
+func TestTemplate_ValidateWebHookTemplates_InvalidTemplate_Error(t *testing.T) {
+
+	//	Arrange
+	hook := data.WebHook{URL: "http://lights/cue", Body: []byte(`{"name": "{{.Name"}`)}
+
+	//	Act
+	err := trigger.ValidateWebHookTemplates(hook)
+
+	//	Assert
+	if err == nil {
+		t.Errorf("ValidateWebHookTemplates failed: Should have returned an error for an unclosed action")
+	}
+}
@@ -0,0 +1,39 @@
+package triggerevent
+
+const (
+	// Activated is for a trigger's pin becoming active (for example: motion detected)
+	Activated = "activated"
+
+	// Deactivated is for a trigger's pin becoming inactive (for example: motion stopped)
+	Deactivated = "deactivated"
+
+	// Press is for a single press of a button trigger
+	Press = "press"
+
+	// DoublePress is for two presses of a button trigger in quick succession
+	DoublePress = "doublepress"
+
+	// LongPress is for a button trigger that's been held down for the long press duration
+	LongPress = "longpress"
+
+	// Hold is for a button trigger that's still being held down.  Repeats at the hold interval
+	Hold = "hold"
+
+	// Scheduled is for a time based trigger reaching its scheduled time
+	Scheduled = "scheduled"
+
+	// Startup is for the service starting
+	Startup = "startup"
+
+	// Shutdown is for the service stopping.  It's sent before the service exits
+	Shutdown = "shutdown"
+
+	// NetworkUp is for the network becoming available
+	NetworkUp = "networkup"
+
+	// MonitorFailure is for a trigger monitor that stopped because of an error
+	MonitorFailure = "monitorfailure"
+
+	// DeliveryFailure is for a webhook that still failed after all of its retries
+	DeliveryFailure = "deliveryfailure"
+)
@@ -0,0 +1,15 @@
+package triggersource
+
+const (
+	// GPIO is for triggers fired by a monitored GPIO pin
+	GPIO = "GPIO"
+
+	// API is for triggers fired using the REST API
+	API = "API"
+
+	// Schedule is for triggers fired by a time based schedule
+	Schedule = "Schedule"
+
+	// System is for triggers fired by a system event
+	System = "System"
+)
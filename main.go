@@ -18,6 +18,10 @@ import (
 // @license.url http://www.apache.org/licenses/LICENSE-2.0.html
 
 // @BasePath /v1
+
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
 func main() {
 	//	Set log info:
 	log.Logger = log.With().Timestamp().Caller().Logger()
@@ -0,0 +1,107 @@
+// Package signature signs fxtrigger webhooks, and verifies them for the servers that receive them.
+//
+// When a webhook has a signing secret, fxtrigger sends two extra headers:
+//
+//	X-Fxtrigger-Timestamp: the time the webhook was sent (in Unix seconds)
+//	X-Fxtrigger-Signature: sha256=<the hex HMAC-SHA256 of the timestamp, a '.' and the body>
+//
+// Receivers (written in Go) can check them with VerifyRequest:
+//
+//	body, err := signature.VerifyRequest(req, secret, 5*time.Minute)
+//	if err != nil {
+//		http.Error(rw, "invalid signature", http.StatusUnauthorized)
+//		return
+//	}
+package signature
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// SignatureHeader is the header the signature is sent in
+	SignatureHeader = "X-Fxtrigger-Signature"
+
+	// TimestampHeader is the header the time the webhook was sent is sent in
+	TimestampHeader = "X-Fxtrigger-Timestamp"
+
+	// signaturePrefix is the algorithm the signature uses
+	signaturePrefix = "sha256="
+)
+
+var (
+	// ErrMissingSignature is returned when a webhook doesn't have a signature or timestamp
+	ErrMissingSignature = errors.New("the webhook isn't signed")
+
+	// ErrInvalidSignature is returned when a webhook signature doesn't match
+	ErrInvalidSignature = errors.New("the webhook signature doesn't match")
+
+	// ErrExpired is returned when a webhook was sent too long ago (it might be a replay)
+	ErrExpired = errors.New("the webhook timestamp is too old")
+)
+
+// Sign returns the signature for a webhook sent at the timestamp (in Unix seconds) with the body
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify makes sure the signature matches the timestamp and body, and that the timestamp is
+// within maxAge of now (so old webhooks can't be replayed).  If maxAge is zero, the age isn't checked
+func Verify(secret, signature, timestamp string, body []byte, maxAge time.Duration) error {
+	if signature == "" || timestamp == "" {
+		return ErrMissingSignature
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body))) {
+		return ErrInvalidSignature
+	}
+
+	if maxAge > 0 {
+		seconds, err := strconv.ParseInt(strings.TrimSpace(timestamp), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid webhook timestamp %q: %w", timestamp, err)
+		}
+
+		age := time.Since(time.Unix(seconds, 0))
+		if age > maxAge || age < -maxAge {
+			return ErrExpired
+		}
+	}
+
+	return nil
+}
+
+// VerifyRequest verifies the signature of a webhook request and returns its body.
+// The request body is replaced, so it can still be read after verifying
+func VerifyRequest(req *http.Request, secret string, maxAge time.Duration) ([]byte, error) {
+	body := []byte{}
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("problem reading the webhook body: %w", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	if err := Verify(secret, req.Header.Get(SignatureHeader), req.Header.Get(TimestampHeader), body, maxAge); err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
@@ -0,0 +1,114 @@
+package signature_test
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danesparza/fxtrigger/signature"
+)
+
+func TestSignature_Verify_ValidSignature_Successful(t *testing.T) {
+
+	//	Arrange
+	body := []byte(`{"light":"on"}`)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	sig := signature.Sign("secret", timestamp, body)
+
+	//	Act
+	err := signature.Verify("secret", sig, timestamp, body, time.Minute)
+
+	//	Assert
+	if err != nil {
+		t.Errorf("Verify failed: Should have verified the signature but got: %v", err)
+	}
+
+	if !strings.HasPrefix(sig, "sha256=") {
+		t.Errorf("Sign failed: Signature should start with the algorithm but got: %v", sig)
+	}
+}
+
+func TestSignature_Verify_ChangedRequest_ReturnsError(t *testing.T) {
+
+	//	Arrange
+	body := []byte(`{"light":"on"}`)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	sig := signature.Sign("secret", timestamp, body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		signature string
+		timestamp string
+		body      []byte
+		want      error
+	}{
+		{"wrong secret", "other", sig, timestamp, body, signature.ErrInvalidSignature},
+		{"changed body", "secret", sig, timestamp, []byte(`{"light":"off"}`), signature.ErrInvalidSignature},
+		{"changed timestamp", "secret", sig, strconv.FormatInt(time.Now().Unix()+1, 10), body, signature.ErrInvalidSignature},
+		{"missing signature", "secret", "", timestamp, body, signature.ErrMissingSignature},
+		{"missing timestamp", "secret", sig, "", body, signature.ErrMissingSignature},
+	}
+
+	for _, tt := range tests {
+		//	Act
+		err := signature.Verify(tt.secret, tt.signature, tt.timestamp, tt.body, time.Minute)
+
+		//	Assert
+		if !errors.Is(err, tt.want) {
+			t.Errorf("Verify failed (%v): Should have returned %v but got: %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+func TestSignature_Verify_OldTimestamp_ReturnsError(t *testing.T) {
+
+	//	Arrange
+	body := []byte(`{"light":"on"}`)
+	timestamp := strconv.FormatInt(time.Now().Add(-10*time.Minute).Unix(), 10)
+	sig := signature.Sign("secret", timestamp, body)
+
+	//	Act
+	err := signature.Verify("secret", sig, timestamp, body, 5*time.Minute)
+	errNoMaxAge := signature.Verify("secret", sig, timestamp, body, 0)
+
+	//	Assert
+	if !errors.Is(err, signature.ErrExpired) {
+		t.Errorf("Verify failed: Should have returned ErrExpired but got: %v", err)
+	}
+
+	if errNoMaxAge != nil {
+		t.Errorf("Verify failed: Shouldn't check the age without a maximum but got: %v", errNoMaxAge)
+	}
+}
+
+func TestSignature_VerifyRequest_ValidRequest_ReturnsBody(t *testing.T) {
+
+	//	Arrange
+	body := `{"light":"on"}`
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req := httptest.NewRequest("POST", "/hook", strings.NewReader(body))
+	req.Header.Set(signature.TimestampHeader, timestamp)
+	req.Header.Set(signature.SignatureHeader, signature.Sign("secret", timestamp, []byte(body)))
+
+	//	Act
+	got, err := signature.VerifyRequest(req, "secret", time.Minute)
+
+	//	Assert
+	if err != nil {
+		t.Fatalf("VerifyRequest failed: Should have verified the request but got: %v", err)
+	}
+
+	if string(got) != body {
+		t.Errorf("VerifyRequest failed: Should have returned the body but got: %v", string(got))
+	}
+
+	if again, err := io.ReadAll(req.Body); err != nil || string(again) != body {
+		t.Errorf("VerifyRequest failed: The body should still be readable but got: %v (%v)", string(again), err)
+	}
+}